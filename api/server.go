@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
@@ -28,31 +29,143 @@ type Config struct {
 	PrometheusLocalPort   uint
 	PrometheusRemotePort  uint
 	PrometheusServiceName string
+
+	// Leadership transfer configuration.
+	EnableLeaderTransfer bool
+
+	// Maintenance configuration.
+	EnableCordon bool
 }
 
 type LeaderStatus struct {
 	IsLeader      bool   `json:"is_leader"`
 	CurrentLeader string `json:"current_leader"`
+	Term          int64  `json:"term"`
+	IsCordoned    bool   `json:"is_cordoned"`
+	Zone          string `json:"zone,omitempty"`
+	LeaderZone    string `json:"leader_zone,omitempty"`
+	// Fitness is the fitness score of the member, only set when the fitness scoring is enabled.
+	Fitness *float64 `json:"fitness,omitempty"`
+	// Slots is only set when running a multi slot election.
+	Slots []SlotStatus `json:"slots,omitempty"`
+	// Global is only set when running a hierarchical election, the top level fields then describe the local election.
+	Global *GlobalLeaderStatus `json:"global,omitempty"`
+}
+
+type GlobalLeaderStatus struct {
+	IsLeader      bool   `json:"is_leader"`
+	CurrentLeader string `json:"current_leader"`
+	Term          int64  `json:"term"`
+}
+
+type MemberStatus struct {
+	MemberID   string `json:"member_id"`
+	State      string `json:"state"`
+	Health     string `json:"health"`
+	ConfigHash string `json:"config_hash"`
+	SourceHash string `json:"source_hash"`
+	// SourceGeneration is only reported with the configuration gating.
+	SourceGeneration int64     `json:"source_generation,omitempty"`
+	Fitness          *float64  `json:"fitness,omitempty"`
+	LastHeartbeat    time.Time `json:"last_heartbeat"`
+	IsStale          bool      `json:"is_stale"`
+}
+
+type HistoryEntry struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Lease  string    `json:"lease"`
+	Leader string    `json:"leader,omitempty"`
+	Term   int64     `json:"term"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+type SlotStatus struct {
+	Slot   int    `json:"slot"`
+	Holder string `json:"holder"`
+	IsHeld bool   `json:"is_held"`
 }
 
-func NewServer(cfg Config, electionStatus election.Status, metricsRegistry prometheus.Gatherer) (*Server, error) {
+func NewServer(cfg Config, electionStatus election.Status, electionController election.Controller, metricsRegistry prometheus.Gatherer) (*Server, error) {
 	var mux http.ServeMux
 
 	mux.HandleFunc("/_elector/leader", func(rw http.ResponseWriter, r *http.Request) {
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(http.StatusOK)
-
-		_ = json.NewEncoder(rw).Encode(LeaderStatus{
-			IsLeader:      electionStatus.IsLeader(),
-			CurrentLeader: electionStatus.GetLeader(),
-		})
+		writeLeaderStatus(rw, electionStatus)
 	})
+	if memberLister, ok := electionStatus.(election.MemberLister); ok {
+		mux.HandleFunc("/_elector/members", func(rw http.ResponseWriter, r *http.Request) {
+			writeMembers(rw, r, memberLister)
+		})
+	}
+	if historyGetter, ok := electionStatus.(election.HistoryGetter); ok {
+		mux.HandleFunc("/_elector/election/history", func(rw http.ResponseWriter, r *http.Request) {
+			writeHistory(rw, historyGetter)
+		})
+	}
 	mux.HandleFunc("/_elector/healthz", func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusOK) })
 	mux.Handle("/_elector/metrics", promhttp.HandlerFor(
 		metricsRegistry,
 		promhttp.HandlerOpts{EnableOpenMetrics: true},
 	))
 
+	if cfg.EnableLeaderTransfer {
+		mux.HandleFunc("/_elector/leader/stepdown", func(rw http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			if err := electionController.StepDown(r.Context()); err != nil {
+				writeControllerError(rw, err)
+				return
+			}
+
+			writeLeaderStatus(rw, electionStatus)
+		})
+		mux.HandleFunc("/_elector/leader/transfer", func(rw http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			if err := electionController.Transfer(r.Context(), r.URL.Query().Get("to")); err != nil {
+				writeControllerError(rw, err)
+				return
+			}
+
+			writeLeaderStatus(rw, electionStatus)
+		})
+	}
+
+	if cfg.EnableCordon {
+		mux.HandleFunc("/_elector/member/cordon", func(rw http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			if err := electionController.Cordon(r.Context()); err != nil {
+				writeControllerError(rw, err)
+				return
+			}
+
+			writeLeaderStatus(rw, electionStatus)
+		})
+		mux.HandleFunc("/_elector/member/uncordon", func(rw http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(rw, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			if err := electionController.Uncordon(r.Context()); err != nil {
+				writeControllerError(rw, err)
+				return
+			}
+
+			writeLeaderStatus(rw, electionStatus)
+		})
+	}
+
 	if cfg.EnableLeaderProxy {
 		leaderProxy, err := newProxy(cfg, electionStatus)
 		if err != nil {
@@ -71,6 +184,113 @@ func NewServer(cfg Config, electionStatus election.Status, metricsRegistry prome
 	}, nil
 }
 
+func writeLeaderStatus(rw http.ResponseWriter, electionStatus election.Status) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+
+	status := LeaderStatus{
+		IsLeader:      electionStatus.IsLeader(),
+		CurrentLeader: electionStatus.GetLeader(),
+		Term:          electionStatus.GetTerm(),
+		IsCordoned:    electionStatus.IsCordoned(),
+		Zone:          electionStatus.GetZone(),
+		LeaderZone:    electionStatus.GetLeaderZone(),
+	}
+
+	if slotGetter, ok := electionStatus.(election.SlotGetter); ok {
+		heldSlot := slotGetter.Slot()
+
+		for slot, holder := range slotGetter.SlotHolders() {
+			status.Slots = append(status.Slots, SlotStatus{
+				Slot:   slot,
+				Holder: holder,
+				IsHeld: slot == heldSlot,
+			})
+		}
+	}
+
+	if fitnessGetter, ok := electionStatus.(election.FitnessGetter); ok {
+		if fitness, ok := fitnessGetter.GetFitness(); ok {
+			status.Fitness = &fitness
+		}
+	}
+
+	if globalGetter, ok := electionStatus.(election.GlobalGetter); ok {
+		global := globalGetter.Global()
+
+		status.Global = &GlobalLeaderStatus{
+			IsLeader:      global.IsLeader(),
+			CurrentLeader: global.GetLeader(),
+			Term:          global.GetTerm(),
+		}
+	}
+
+	_ = json.NewEncoder(rw).Encode(status)
+}
+
+func writeMembers(rw http.ResponseWriter, r *http.Request, memberLister election.MemberLister) {
+	members, err := memberLister.Members(r.Context())
+	if err != nil {
+		klog.ErrorS(err, "unable to list the election members")
+		http.Error(rw, "Something unexpected happened", http.StatusInternalServerError)
+		return
+	}
+
+	statuses := make([]MemberStatus, len(members))
+
+	for i, member := range members {
+		statuses[i] = MemberStatus{
+			MemberID:         member.ID,
+			State:            member.State,
+			Health:           member.Health,
+			ConfigHash:       member.ConfigHash,
+			SourceHash:       member.SourceHash,
+			SourceGeneration: member.SourceGeneration,
+			Fitness:          member.Fitness,
+			LastHeartbeat:    member.LastHeartbeat,
+			IsStale:          member.Stale,
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+
+	_ = json.NewEncoder(rw).Encode(statuses)
+}
+
+func writeHistory(rw http.ResponseWriter, historyGetter election.HistoryGetter) {
+	events := historyGetter.History()
+	entries := make([]HistoryEntry, len(events))
+
+	for i, evt := range events {
+		entries[i] = HistoryEntry{
+			Time:   evt.Time,
+			Type:   evt.Type,
+			Lease:  evt.Lease,
+			Leader: evt.Leader,
+			Term:   evt.Term,
+			Reason: evt.Reason,
+		}
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+
+	_ = json.NewEncoder(rw).Encode(entries)
+}
+
+func writeControllerError(rw http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, election.ErrInvalidMember):
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, election.ErrNotLeader), errors.Is(err, election.ErrNotRunning), errors.Is(err, election.ErrCordoned):
+		http.Error(rw, err.Error(), http.StatusConflict)
+	default:
+		klog.ErrorS(err, "unable to update the election")
+		http.Error(rw, "Something unexpected happened", http.StatusInternalServerError)
+	}
+}
+
 func (s *Server) Serve(ctx context.Context) error {
 	shutdownDone := make(chan error)
 
@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/jlevesy/prometheus-elector/api"
+	"github.com/jlevesy/prometheus-elector/election"
 )
 
 func TestServer_ServeHTTP_ProxyNotLeaderForwardsToLeader(t *testing.T) {
@@ -53,6 +54,7 @@ func TestServer_ServeHTTP_ProxyNotLeaderForwardsToLeader(t *testing.T) {
 			isLeader: false,
 			leader:   "bozo",
 		},
+		nil,
 		prometheus.NewRegistry(),
 	)
 	require.NoError(t, err)
@@ -114,6 +116,7 @@ func TestServer_ServeHTTP_ProxyIsLeaderForwardsToLocalhost(t *testing.T) {
 			isLeader: true,
 			leader:   "bozo",
 		},
+		nil,
 		prometheus.NewRegistry(),
 	)
 	require.NoError(t, err)
@@ -151,9 +154,13 @@ func TestServer_ServeHTTP_ProxyDisabled(t *testing.T) {
 			ShutdownGraceDelay: 15 * time.Second,
 		},
 		&leaderStatusStub{
-			isLeader: true,
-			leader:   "bozo",
+			isLeader:   true,
+			leader:     "bozo",
+			zone:       "zone-a",
+			leaderZone: "zone-a",
+			term:       4,
 		},
+		nil,
 		prometheus.NewRegistry(),
 	)
 	require.NoError(t, err)
@@ -186,6 +193,9 @@ func TestServer_ServeHTTP_ProxyDisabled(t *testing.T) {
 		api.LeaderStatus{
 			IsLeader:      true,
 			CurrentLeader: "bozo",
+			Term:          4,
+			Zone:          "zone-a",
+			LeaderZone:    "zone-a",
 		},
 		gotLeaderStatus,
 	)
@@ -198,6 +208,422 @@ func TestServer_ServeHTTP_ProxyDisabled(t *testing.T) {
 	<-srvDone
 }
 
+func TestServer_LeaderTransfer(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		srvDone     = make(chan struct{})
+		controller  = &controllerStub{}
+	)
+
+	defer cancel()
+
+	srv, err := api.NewServer(
+		api.Config{
+			ListenAddress:        ":63549",
+			ShutdownGraceDelay:   15 * time.Second,
+			EnableLeaderTransfer: true,
+		},
+		&leaderStatusStub{
+			isLeader: true,
+			leader:   "bozo",
+		},
+		controller,
+		prometheus.NewRegistry(),
+	)
+	require.NoError(t, err)
+
+	go func() {
+		err := srv.Serve(ctx)
+		require.NoError(t, err)
+
+		close(srvDone)
+	}()
+
+	require.NoError(t, waitForServerReady(5))
+
+	resp, err := http.Get("http://localhost:63549/_elector/leader/stepdown")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	resp, err = http.Post("http://localhost:63549/_elector/leader/stepdown", "", http.NoBody)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, controller.stepDownCalled)
+
+	resp, err = http.Post("http://localhost:63549/_elector/leader/transfer?to=bozo-1", "", http.NoBody)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "bozo-1", controller.transferredTo)
+
+	controller.err = election.ErrNotLeader
+
+	resp, err = http.Post("http://localhost:63549/_elector/leader/stepdown", "", http.NoBody)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	controller.err = election.ErrCordoned
+
+	resp, err = http.Post("http://localhost:63549/_elector/leader/stepdown", "", http.NoBody)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	controller.err = election.ErrInvalidMember
+
+	resp, err = http.Post("http://localhost:63549/_elector/leader/transfer", "", http.NoBody)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	cancel()
+	<-srvDone
+}
+
+func TestServer_Cordon(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		srvDone     = make(chan struct{})
+		controller  = &controllerStub{}
+	)
+
+	defer cancel()
+
+	srv, err := api.NewServer(
+		api.Config{
+			ListenAddress:      ":63549",
+			ShutdownGraceDelay: 15 * time.Second,
+			EnableCordon:       true,
+		},
+		&leaderStatusStub{
+			isLeader:   false,
+			leader:     "bozo",
+			isCordoned: true,
+		},
+		controller,
+		prometheus.NewRegistry(),
+	)
+	require.NoError(t, err)
+
+	go func() {
+		err := srv.Serve(ctx)
+		require.NoError(t, err)
+
+		close(srvDone)
+	}()
+
+	require.NoError(t, waitForServerReady(5))
+
+	resp, err := http.Post("http://localhost:63549/_elector/member/cordon", "", http.NoBody)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, controller.cordoned)
+
+	defer resp.Body.Close()
+
+	var gotLeaderStatus api.LeaderStatus
+
+	err = json.NewDecoder(resp.Body).Decode(&gotLeaderStatus)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		api.LeaderStatus{
+			IsLeader:      false,
+			CurrentLeader: "bozo",
+			IsCordoned:    true,
+		},
+		gotLeaderStatus,
+	)
+
+	resp, err = http.Post("http://localhost:63549/_elector/member/uncordon", "", http.NoBody)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, controller.cordoned)
+
+	resp, err = http.Post("http://localhost:63549/_elector/leader/stepdown", "", http.NoBody)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	cancel()
+	<-srvDone
+}
+
+func TestServer_LeaderSlots(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		srvDone     = make(chan struct{})
+	)
+
+	defer cancel()
+
+	srv, err := api.NewServer(
+		api.Config{
+			ListenAddress:      ":63549",
+			ShutdownGraceDelay: 15 * time.Second,
+		},
+		&slotStatusStub{
+			leaderStatusStub: leaderStatusStub{
+				isLeader: true,
+				leader:   "bozo-0",
+			},
+			slot:    1,
+			holders: []string{"bozo-0", "bozo-1", ""},
+		},
+		nil,
+		prometheus.NewRegistry(),
+	)
+	require.NoError(t, err)
+
+	go func() {
+		err := srv.Serve(ctx)
+		require.NoError(t, err)
+
+		close(srvDone)
+	}()
+
+	require.NoError(t, waitForServerReady(5))
+
+	resp, err := http.Get("http://localhost:63549/_elector/leader")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	defer resp.Body.Close()
+
+	var gotLeaderStatus api.LeaderStatus
+
+	err = json.NewDecoder(resp.Body).Decode(&gotLeaderStatus)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		api.LeaderStatus{
+			IsLeader:      true,
+			CurrentLeader: "bozo-0",
+			Slots: []api.SlotStatus{
+				{Slot: 0, Holder: "bozo-0"},
+				{Slot: 1, Holder: "bozo-1", IsHeld: true},
+				{Slot: 2, Holder: ""},
+			},
+		},
+		gotLeaderStatus,
+	)
+
+	cancel()
+	<-srvDone
+}
+
+func TestServer_LeaderGlobal(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		srvDone     = make(chan struct{})
+	)
+
+	defer cancel()
+
+	srv, err := api.NewServer(
+		api.Config{
+			ListenAddress:      ":63549",
+			ShutdownGraceDelay: 15 * time.Second,
+		},
+		&globalStatusStub{
+			leaderStatusStub: leaderStatusStub{
+				isLeader: true,
+				leader:   "bozo-0",
+				term:     3,
+			},
+			global: &leaderStatusStub{
+				isLeader: false,
+				leader:   "west/bozo-1",
+				term:     12,
+			},
+		},
+		nil,
+		prometheus.NewRegistry(),
+	)
+	require.NoError(t, err)
+
+	go func() {
+		err := srv.Serve(ctx)
+		require.NoError(t, err)
+
+		close(srvDone)
+	}()
+
+	require.NoError(t, waitForServerReady(5))
+
+	resp, err := http.Get("http://localhost:63549/_elector/leader")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	defer resp.Body.Close()
+
+	var gotLeaderStatus api.LeaderStatus
+
+	err = json.NewDecoder(resp.Body).Decode(&gotLeaderStatus)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		api.LeaderStatus{
+			IsLeader:      true,
+			CurrentLeader: "bozo-0",
+			Term:          3,
+			Global: &api.GlobalLeaderStatus{
+				IsLeader:      false,
+				CurrentLeader: "west/bozo-1",
+				Term:          12,
+			},
+		},
+		gotLeaderStatus,
+	)
+
+	cancel()
+	<-srvDone
+}
+
+func TestServer_Members(t *testing.T) {
+	var (
+		ctx, cancel   = context.WithCancel(context.Background())
+		srvDone       = make(chan struct{})
+		lastHeartbeat = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+		fitness       = 0.75
+	)
+
+	defer cancel()
+
+	srv, err := api.NewServer(
+		api.Config{
+			ListenAddress:      ":63549",
+			ShutdownGraceDelay: 15 * time.Second,
+		},
+		&memberListerStub{
+			leaderStatusStub: leaderStatusStub{
+				isLeader: true,
+				leader:   "bozo-0",
+			},
+			members: []election.Member{
+				{
+					ID:            "bozo-0",
+					State:         election.MemberStateLeader,
+					Health:        election.MemberHealthHealthy,
+					ConfigHash:    "abc",
+					Fitness:       &fitness,
+					LastHeartbeat: lastHeartbeat,
+				},
+				{
+					ID:            "bozo-1",
+					State:         election.MemberStateLeft,
+					Health:        election.MemberHealthUnhealthy,
+					ConfigHash:    "def",
+					LastHeartbeat: lastHeartbeat,
+					Stale:         true,
+				},
+			},
+		},
+		nil,
+		prometheus.NewRegistry(),
+	)
+	require.NoError(t, err)
+
+	go func() {
+		err := srv.Serve(ctx)
+		require.NoError(t, err)
+
+		close(srvDone)
+	}()
+
+	require.NoError(t, waitForServerReady(5))
+
+	resp, err := http.Get("http://localhost:63549/_elector/members")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	defer resp.Body.Close()
+
+	var gotMembers []api.MemberStatus
+
+	err = json.NewDecoder(resp.Body).Decode(&gotMembers)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]api.MemberStatus{
+			{
+				MemberID:      "bozo-0",
+				State:         "leader",
+				Health:        "healthy",
+				ConfigHash:    "abc",
+				Fitness:       &fitness,
+				LastHeartbeat: lastHeartbeat,
+			},
+			{
+				MemberID:      "bozo-1",
+				State:         "left",
+				Health:        "unhealthy",
+				ConfigHash:    "def",
+				LastHeartbeat: lastHeartbeat,
+				IsStale:       true,
+			},
+		},
+		gotMembers,
+	)
+
+	cancel()
+	<-srvDone
+}
+
+func TestServer_History(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		srvDone     = make(chan struct{})
+		at          = time.Date(2023, time.January, 1, 2, 0, 0, 0, time.UTC)
+	)
+
+	defer cancel()
+
+	srv, err := api.NewServer(
+		api.Config{
+			ListenAddress:      ":63549",
+			ShutdownGraceDelay: 15 * time.Second,
+		},
+		&historyGetterStub{
+			leaderStatusStub: leaderStatusStub{leader: "bozo-1"},
+			events: []election.HistoryEvent{
+				{Time: at, Type: election.HistoryEventLost, Lease: "lease", Leader: "bozo-0", Term: 2, Reason: "unable to renew the lease"},
+				{Time: at.Add(time.Minute), Type: election.HistoryEventNewLeader, Lease: "lease", Leader: "bozo-1", Term: 3},
+			},
+		},
+		nil,
+		prometheus.NewRegistry(),
+	)
+	require.NoError(t, err)
+
+	go func() {
+		err := srv.Serve(ctx)
+		require.NoError(t, err)
+
+		close(srvDone)
+	}()
+
+	require.NoError(t, waitForServerReady(5))
+
+	resp, err := http.Get("http://localhost:63549/_elector/election/history")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	defer resp.Body.Close()
+
+	var gotHistory []api.HistoryEntry
+
+	err = json.NewDecoder(resp.Body).Decode(&gotHistory)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]api.HistoryEntry{
+			{Time: at, Type: "lost", Lease: "lease", Leader: "bozo-0", Term: 2, Reason: "unable to renew the lease"},
+			{Time: at.Add(time.Minute), Type: "new_leader", Lease: "lease", Leader: "bozo-1", Term: 3},
+		},
+		gotHistory,
+	)
+
+	cancel()
+	<-srvDone
+}
+
 func waitForServerReady(maxAttempts int) error {
 	var attempt int
 
@@ -224,9 +650,79 @@ func waitForServerReady(maxAttempts int) error {
 }
 
 type leaderStatusStub struct {
-	leader   string
-	isLeader bool
+	leader     string
+	isLeader   bool
+	isCordoned bool
+	zone       string
+	leaderZone string
+	term       int64
+}
+
+func (s *leaderStatusStub) IsLeader() bool        { return s.isLeader }
+func (s *leaderStatusStub) GetLeader() string     { return s.leader }
+func (s *leaderStatusStub) IsCordoned() bool      { return s.isCordoned }
+func (s *leaderStatusStub) GetZone() string       { return s.zone }
+func (s *leaderStatusStub) GetLeaderZone() string { return s.leaderZone }
+func (s *leaderStatusStub) GetTerm() int64        { return s.term }
+
+type slotStatusStub struct {
+	leaderStatusStub
+
+	slot    int
+	holders []string
+}
+
+func (s *slotStatusStub) Slot() int             { return s.slot }
+func (s *slotStatusStub) SlotHolders() []string { return s.holders }
+
+type globalStatusStub struct {
+	leaderStatusStub
+
+	global election.Status
 }
 
-func (s *leaderStatusStub) IsLeader() bool    { return s.isLeader }
-func (s *leaderStatusStub) GetLeader() string { return s.leader }
+func (s *globalStatusStub) Global() election.Status { return s.global }
+
+type memberListerStub struct {
+	leaderStatusStub
+
+	members []election.Member
+}
+
+func (s *memberListerStub) Members(context.Context) ([]election.Member, error) { return s.members, nil }
+
+type historyGetterStub struct {
+	leaderStatusStub
+
+	events []election.HistoryEvent
+}
+
+func (s *historyGetterStub) History() []election.HistoryEvent { return s.events }
+
+type controllerStub struct {
+	err error
+
+	stepDownCalled int
+	transferredTo  string
+	cordoned       bool
+}
+
+func (s *controllerStub) StepDown(context.Context) error {
+	s.stepDownCalled++
+	return s.err
+}
+
+func (s *controllerStub) Transfer(_ context.Context, to string) error {
+	s.transferredTo = to
+	return s.err
+}
+
+func (s *controllerStub) Cordon(context.Context) error {
+	s.cordoned = true
+	return s.err
+}
+
+func (s *controllerStub) Uncordon(context.Context) error {
+	s.cordoned = false
+	return s.err
+}
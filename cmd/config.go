@@ -4,12 +4,23 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"golang.org/x/net/http/httpguts"
+	coordinationv1 "k8s.io/api/coordination/v1"
+
+	"github.com/jlevesy/prometheus-elector/config"
+)
+
+const (
+	electionModeMember   = "member"
+	electionModeObserver = "observer"
 )
 
 type cliConfig struct {
@@ -19,17 +30,75 @@ type cliConfig struct {
 	init bool
 
 	// Config and output paths.
-	configPath string
-	outputPath string
+	configPath             string
+	outputPath             string
+	configTemplatesEnabled bool
 
 	// Runtime config.
 	// Election setup.
-	memberID           string
-	leaseName          string
-	leaseNamespace     string
-	leaseDuration      time.Duration
-	leaseRenewDeadline time.Duration
-	leaseRetryPeriod   time.Duration
+	electionMode          string
+	memberID              string
+	leaseName             string
+	leaseNamespace        string
+	leaseDuration         time.Duration
+	leaseRenewDeadline    time.Duration
+	leaseRetryPeriod      time.Duration
+	leaderTransferTimeout time.Duration
+	electionSlots         int
+
+	// Graceful handover on shutdown.
+	electionHandoverTimeout time.Duration
+
+	// Handover policy.
+	electionHandoverPolicy string
+	electionHandoverDelay  time.Duration
+
+	// Topology aware election.
+	electionPreferredZone         string
+	electionOutOfZoneAcquireDelay time.Duration
+
+	// Coordinated election.
+	electionCoordinated               bool
+	electionCoordinatedStrategy       string
+	electionCandidateBinaryVersion    string
+	electionCandidateEmulationVersion string
+	electionCandidatePriority         int
+
+	// Hierarchical election.
+	globalLeaseName      string
+	globalLeaseNamespace string
+	globalKubeConfigPath string
+	globalClusterName    string
+
+	// Split-brain detection.
+	splitBrainCheckPeriod time.Duration
+	splitBrainThreshold   time.Duration
+	splitBrainStepDown    bool
+
+	// Leader fitness scoring.
+	fitnessQueries       stringsFlag
+	fitnessPrometheusURL string
+	fitnessPeriod        time.Duration
+	fitnessThreshold     float64
+
+	// Step down when the leader configuration can't be applied.
+	electionApplyFailureThreshold int
+	electionApplyFailureCooldown  time.Duration
+
+	// Membership registry.
+	electionMemberHeartbeatPeriod time.Duration
+	electionMemberStaleTimeout    time.Duration
+	electionConfigGating          bool
+	electionConfigGatingConfigMap string
+
+	// Election history.
+	electionHistorySize int
+	electionHistoryPath string
+
+	// Anti-flap protection.
+	electionMinLeadershipDuration time.Duration
+	electionRejoinCooldown        time.Duration
+	electionMaxRejoinCooldown     time.Duration
 
 	// How to notify prometheus for an update.
 	notifyHTTPURL          string
@@ -38,6 +107,10 @@ type cliConfig struct {
 	notifyRetryDelay       time.Duration
 	notifyTimeout          time.Duration
 
+	// How to detect and repair configuration drifts.
+	resyncPeriod     time.Duration
+	resyncMetricsURL string
+
 	// How to wait for prometheus to be ready.
 	readinessHTTPURL    string
 	readinessPollPeriod time.Duration
@@ -57,6 +130,28 @@ type cliConfig struct {
 	apiProxyPrometheusLocalPort   uint
 	apiProxyPrometheusRemotePort  uint
 	apiProxyPrometheusServiceName string
+	apiLeaderTransferEnabled      bool
+	apiCordonEnabled              bool
+
+	// Leader EndpointSlice.
+	leaderServiceName     string
+	leaderServicePort     uint
+	leaderServicePortName string
+
+	// Reflect the role on the member pod.
+	podRoleEnabled bool
+
+	// Transition hooks.
+	hookExecCommands     stringsFlag
+	hookWebhookURLs      stringsFlag
+	hookTimeout          time.Duration
+	hookRetryMaxAttempts int
+	hookRetryDelay       time.Duration
+
+	// Kubernetes events.
+	eventsEnabled      bool
+	eventsBurst        int
+	eventsRefillPeriod time.Duration
 
 	runtimeMetrics bool
 
@@ -71,6 +166,11 @@ func newCLIConfig() cliConfig {
 }
 
 func (c *cliConfig) validateInitConfig() error {
+	// An observer doesn't manage any Prometheus configuration.
+	if c.observer() && !c.init {
+		return nil
+	}
+
 	if c.configPath == "" {
 		return errors.New("missing config flag")
 	}
@@ -100,6 +200,249 @@ func (c *cliConfig) validateRuntimeConfig() error {
 		}
 	}
 
+	if c.electionMode != electionModeMember && c.electionMode != electionModeObserver {
+		return errors.New("invalid election-mode, should be member or observer")
+	}
+
+	if c.leaderTransferTimeout < 1 {
+		return errors.New("invalid leader-transfer-timeout, should be >= 1")
+	}
+
+	if c.electionSlots < 1 {
+		return errors.New("invalid election-slots, should be >= 1")
+	}
+
+	if c.electionSlots > 1 && !c.configTemplatesEnabled {
+		return errors.New("election-slots > 1 requires config-templates-enabled, used to shard the leader configuration by slot")
+	}
+
+	if c.electionSlots > 1 && (c.apiLeaderTransferEnabled || c.apiCordonEnabled) {
+		return errors.New("api-leader-transfer-enabled and api-cordon-enabled are not supported when election-slots > 1")
+	}
+
+	if c.electionHandoverTimeout < 0 {
+		return errors.New("invalid election-handover-timeout, should be >= 0")
+	}
+
+	if c.electionSlots > 1 && c.electionHandoverTimeout > 0 {
+		return errors.New("election-handover-timeout is not supported when election-slots > 1")
+	}
+
+	switch c.electionHandoverPolicy {
+	case config.HandoverImmediate:
+	case config.HandoverOverlap, config.HandoverQuiet:
+		if c.electionHandoverDelay <= 0 {
+			return errors.New("invalid election-handover-delay, should be > 0 when election-handover-policy is overlap or quiet")
+		}
+	default:
+		return errors.New("invalid election-handover-policy, should be immediate, overlap or quiet")
+	}
+
+	if c.electionOutOfZoneAcquireDelay < 0 {
+		return errors.New("invalid election-out-of-zone-acquire-delay, should be >= 0")
+	}
+
+	if c.electionSlots > 1 && c.electionPreferredZone != "" {
+		return errors.New("election-preferred-zone is not supported when election-slots > 1")
+	}
+
+	if c.electionCoordinated {
+		if c.electionCandidateBinaryVersion == "" {
+			return errors.New("missing election-candidate-binary-version flag, required when election-coordinated is set")
+		}
+
+		if c.electionCoordinatedStrategy == "" {
+			return errors.New("missing election-coordinated-strategy flag, required when election-coordinated is set")
+		}
+
+		if c.electionSlots > 1 {
+			return errors.New("election-coordinated is not supported when election-slots > 1")
+		}
+
+		if c.electionPreferredZone != "" || c.apiLeaderTransferEnabled || c.apiCordonEnabled {
+			return errors.New("election-preferred-zone, api-leader-transfer-enabled and api-cordon-enabled are not supported when election-coordinated is set")
+		}
+	}
+
+	if c.globalLeaseName != "" {
+		if c.globalLeaseNamespace == "" {
+			return errors.New("missing global-lease-namespace flag, required when global-lease-name is set")
+		}
+
+		if c.globalKubeConfigPath == "" {
+			return errors.New("missing global-kubeconfig flag, required when global-lease-name is set")
+		}
+
+		if c.globalClusterName == "" {
+			return errors.New("missing global-cluster-name flag, required when global-lease-name is set")
+		}
+
+		if c.electionSlots > 1 || c.electionCoordinated || c.electionHandoverTimeout > 0 {
+			return errors.New("election-slots > 1, election-coordinated and election-handover-timeout are not supported when global-lease-name is set")
+		}
+	}
+
+	if c.splitBrainCheckPeriod < 0 {
+		return errors.New("invalid split-brain-check-period, should be >= 0")
+	}
+
+	if c.splitBrainCheckPeriod > 0 {
+		if c.splitBrainThreshold < 0 {
+			return errors.New("invalid split-brain-threshold, should be >= 0")
+		}
+
+		if c.apiProxyPrometheusServiceName == "" {
+			return errors.New("split-brain-check-period requires api-proxy-prometheus-service-name, used to reach the peers")
+		}
+
+		if c.electionMemberHeartbeatPeriod == 0 {
+			return errors.New("split-brain-check-period requires election-member-heartbeat-period > 0, used to discover the peers")
+		}
+
+		if c.electionSlots > 1 {
+			return errors.New("split-brain-check-period is not supported when election-slots > 1")
+		}
+
+		if _, err := c.apiPort(); err != nil {
+			return errors.New("invalid api-listen-address, should be [host]:port when split-brain-check-period > 0")
+		}
+	}
+
+	if len(c.fitnessQueries) > 0 {
+		if c.fitnessPrometheusURL == "" {
+			return errors.New("missing fitness-prometheus-url flag, required when fitness-query is set")
+		}
+
+		if c.fitnessPeriod <= 0 {
+			return errors.New("invalid fitness-period, should be > 0")
+		}
+
+		if c.fitnessThreshold < 0 || c.fitnessThreshold > 1 {
+			return errors.New("invalid fitness-threshold, should be between 0 and 1")
+		}
+
+		if c.fitnessThreshold > 0 {
+			if c.electionMemberHeartbeatPeriod == 0 {
+				return errors.New("fitness-threshold requires election-member-heartbeat-period > 0, used to share the fitness of the members")
+			}
+
+			if c.electionSlots > 1 || c.electionCoordinated {
+				return errors.New("fitness-threshold is not supported when election-slots > 1 or election-coordinated is set")
+			}
+		}
+	}
+
+	if c.electionApplyFailureThreshold < 0 {
+		return errors.New("invalid election-apply-failure-threshold, should be >= 0")
+	}
+
+	if c.electionApplyFailureThreshold > 0 {
+		if c.electionApplyFailureCooldown <= 0 {
+			return errors.New("invalid election-apply-failure-cooldown, should be > 0")
+		}
+
+		if c.electionSlots > 1 || c.electionCoordinated {
+			return errors.New("election-apply-failure-threshold is not supported when election-slots > 1 or election-coordinated is set")
+		}
+	}
+
+	if c.electionMemberHeartbeatPeriod < 0 {
+		return errors.New("invalid election-member-heartbeat-period, should be >= 0")
+	}
+
+	if c.electionMemberStaleTimeout < c.electionMemberHeartbeatPeriod {
+		return errors.New("invalid election-member-stale-timeout, should be >= election-member-heartbeat-period")
+	}
+
+	if c.apiLeaderTransferEnabled && c.electionMemberHeartbeatPeriod == 0 {
+		return errors.New("api-leader-transfer-enabled requires election-member-heartbeat-period > 0, used to check the successor")
+	}
+
+	if c.electionConfigGating {
+		if c.electionMemberHeartbeatPeriod == 0 {
+			return errors.New("election-config-gating requires election-member-heartbeat-period > 0, used to compare the configurations")
+		}
+
+		if c.electionConfigGatingConfigMap == "" {
+			return errors.New("election-config-gating requires election-config-gating-configmap, used to order the configurations")
+		}
+
+		if c.electionCoordinated {
+			return errors.New("election-config-gating is not supported when election-coordinated is set")
+		}
+	}
+
+	if c.electionHistorySize < 0 {
+		return errors.New("invalid election-history-size, should be >= 0")
+	}
+
+	if c.electionMinLeadershipDuration < 0 {
+		return errors.New("invalid election-min-leadership-duration, should be >= 0")
+	}
+
+	if c.electionRejoinCooldown < 0 {
+		return errors.New("invalid election-rejoin-cooldown, should be >= 0")
+	}
+
+	if c.electionMaxRejoinCooldown < c.electionRejoinCooldown {
+		return errors.New("invalid election-max-rejoin-cooldown, should be >= election-rejoin-cooldown")
+	}
+
+	if c.leaderServiceName != "" {
+		if c.leaderServicePort == 0 || c.leaderServicePort > math.MaxUint16 {
+			return errors.New("invalid leader-service-port, should be > 0 and <= 65535")
+		}
+
+		if c.electionSlots > 1 {
+			return errors.New("leader-service-name is not supported when election-slots > 1")
+		}
+	}
+
+	if c.observer() {
+		if name := c.memberOnlyFlag(); name != "" {
+			return fmt.Errorf("%s is not supported in observer mode", name)
+		}
+	} else if err := c.validateMemberConfig(); err != nil {
+		return err
+	}
+
+	if c.eventsEnabled {
+		if c.eventsBurst < 1 {
+			return errors.New("invalid events-burst, should be >= 1")
+		}
+
+		if c.eventsRefillPeriod <= 0 {
+			return errors.New("invalid events-refill-period, should be > 0")
+		}
+	}
+
+	if c.apiListenAddr == "" {
+		return errors.New("missing api-listen-address")
+	}
+
+	if c.apiShutdownGraceDelay < 0 {
+		return errors.New("invalid api-shudown-grace-delay, should be >= 0")
+	}
+
+	if c.apiProxyEnabled {
+		if c.apiProxyPrometheusLocalPort == 0 {
+			return errors.New("invalid api-proxy-prometheus-local-port, should be > 0")
+		}
+
+		if c.apiProxyPrometheusRemotePort == 0 {
+			return errors.New("invalid api-proxy-prometheus-remote-port, should be > 0")
+		}
+
+		if c.apiProxyPrometheusServiceName == "" {
+			return errors.New("missing api-proxy-prometheus-service-name")
+		}
+	}
+
+	return nil
+}
+
+// validateMemberConfig validates the configuration of the local Prometheus management, only required when competing.
+func (c *cliConfig) validateMemberConfig() error {
 	if c.notifyHTTPURL == "" {
 		return errors.New("missing notify-http-url flag")
 	}
@@ -120,6 +463,14 @@ func (c *cliConfig) validateRuntimeConfig() error {
 		return errors.New("invalid notify-timeout, should be >= 1")
 	}
 
+	if c.resyncPeriod < 0 {
+		return errors.New("invalid resync-period, should be >= 0")
+	}
+
+	if c.resyncMetricsURL != "" && c.resyncPeriod == 0 {
+		return errors.New("resync-metrics-url requires resync-period > 0")
+	}
+
 	if c.readinessPollPeriod < 1 {
 		return errors.New("invalid readiness-poll-period, should be >= 1")
 	}
@@ -148,44 +499,146 @@ func (c *cliConfig) validateRuntimeConfig() error {
 		return errors.New("invalid readiness-timeout, should be >= 1")
 	}
 
-	if c.apiListenAddr == "" {
-		return errors.New("missing api-listen-address")
-	}
-
-	if c.apiShutdownGraceDelay < 0 {
-		return errors.New("invalid api-shudown-grace-delay, should be >= 0")
-	}
-
-	if c.apiProxyEnabled {
-		if c.apiProxyPrometheusLocalPort == 0 {
-			return errors.New("invalid api-proxy-prometheus-local-port, should be > 0")
+	if len(c.hookExecCommands) > 0 || len(c.hookWebhookURLs) > 0 {
+		if c.hookTimeout <= 0 {
+			return errors.New("invalid hook-timeout, should be > 0")
 		}
 
-		if c.apiProxyPrometheusRemotePort == 0 {
-			return errors.New("invalid api-proxy-prometheus-remote-port, should be > 0")
+		if c.hookRetryMaxAttempts < 1 {
+			return errors.New("invalid hook-retry-max-attempts, should be >= 1")
 		}
 
-		if c.apiProxyPrometheusServiceName == "" {
-			return errors.New("missing api-proxy-prometheus-service-name")
+		if c.hookRetryDelay < 0 {
+			return errors.New("invalid hook-retry-delay, should be >= 0")
 		}
 	}
 
 	return nil
 }
 
+func (c *cliConfig) observer() bool {
+	return c.electionMode == electionModeObserver
+}
+
+// globalMemberID returns the identity of the member in the global election.
+func (c *cliConfig) globalMemberID() string {
+	return c.globalClusterName + "/" + c.memberID
+}
+
+// apiPort returns the port the API listens on, the API of the peers is expected to listen on the same port.
+func (c *cliConfig) apiPort() (uint, error) {
+	_, port, err := net.SplitHostPort(c.apiListenAddr)
+	if err != nil {
+		return 0, err
+	}
+
+	parsed, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q: %w", port, err)
+	}
+
+	return uint(parsed), nil
+}
+
+// memberOnlyFlag returns the name of the first flag set that requires to compete for the leadership, if any.
+func (c *cliConfig) memberOnlyFlag() string {
+	switch {
+	case c.electionSlots > 1:
+		return "election-slots"
+	case c.apiLeaderTransferEnabled:
+		return "api-leader-transfer-enabled"
+	case c.apiCordonEnabled:
+		return "api-cordon-enabled"
+	case c.leaderServiceName != "":
+		return "leader-service-name"
+	case c.podRoleEnabled:
+		return "pod-role-enabled"
+	case c.electionHandoverTimeout > 0:
+		return "election-handover-timeout"
+	case c.electionHandoverPolicy != config.HandoverImmediate:
+		return "election-handover-policy"
+	case c.electionCoordinated:
+		return "election-coordinated"
+	case c.globalLeaseName != "":
+		return "global-lease-name"
+	case c.splitBrainCheckPeriod > 0:
+		return "split-brain-check-period"
+	case c.electionConfigGating:
+		return "election-config-gating"
+	case c.electionApplyFailureThreshold > 0:
+		return "election-apply-failure-threshold"
+	case len(c.fitnessQueries) > 0:
+		return "fitness-query"
+	case len(c.hookExecCommands) > 0:
+		return "hook-exec"
+	case len(c.hookWebhookURLs) > 0:
+		return "hook-webhook-url"
+	default:
+		return ""
+	}
+}
+
 func (c *cliConfig) setupFlags() {
 	flag.BoolVar(&c.init, "init", false, "Only init the prometheus config file")
 
+	flag.StringVar(&c.electionMode, "election-mode", electionModeMember, "Either member, to compete for the leadership, or observer, to only follow the election and proxy to the leader")
 	flag.StringVar(&c.leaseName, "lease-name", "", "Name of lease resource")
 	flag.StringVar(&c.leaseNamespace, "lease-namespace", "", "Name of lease resource namespace")
 	flag.DurationVar(&c.leaseDuration, "lease-duration", 10*time.Second, "Duration of a lease, client wait the full duration of a lease before trying to take it over")
 	flag.DurationVar(&c.leaseRenewDeadline, "lease-renew-deadline", 8*time.Second, "Maximum duration spent trying to renew the lease")
 	flag.DurationVar(&c.leaseRetryPeriod, "lease-retry-period", 2*time.Second, "Delay between two attempts of taking/renewing the lease")
+	flag.DurationVar(&c.leaderTransferTimeout, "leader-transfer-timeout", 30*time.Second, "How long a member that stepped down or transferred its leadership refuses to take the lease back")
+
+	flag.IntVar(&c.electionSlots, "election-slots", 1, "Number of leaders to elect, each leader holds one slot exposed to the leader configuration templates, requires config-templates-enabled when > 1")
+
+	flag.DurationVar(&c.electionHandoverTimeout, "election-handover-timeout", 0, "How long a leader leaving the election on shutdown keeps its configuration and waits for the next leader to apply its own, 0 disables it")
+
+	flag.StringVar(&c.electionHandoverPolicy, "election-handover-policy", config.HandoverImmediate, "How the configuration follows the role changes: immediate, overlap to keep the leader configuration for election-handover-delay after losing the leadership, or quiet to wait for election-handover-delay before applying the leader configuration")
+	flag.DurationVar(&c.electionHandoverDelay, "election-handover-delay", 0, "How long the configuration change is delayed by the overlap and quiet handover policies")
+
+	flag.StringVar(&c.electionPreferredZone, "election-preferred-zone", "", "Zone where the leader should run, members of other zones delay their acquisition attempts and hand over the leadership to a member of this zone")
+	flag.DurationVar(&c.electionOutOfZoneAcquireDelay, "election-out-of-zone-acquire-delay", 10*time.Second, "How long members outside of the preferred zone wait before acquiring an available lease")
+
+	flag.BoolVar(&c.electionCoordinated, "election-coordinated", false, "Register the member as a LeaseCandidate and let the Kubernetes coordinated leader election controller pick the leader, requires Kubernetes 1.33 or later with the CoordinatedLeaderElection feature gate")
+	flag.StringVar(&c.electionCoordinatedStrategy, "election-coordinated-strategy", string(coordinationv1.OldestEmulationVersion), "Strategy supported by the member, used by the coordinator to pick the leader")
+	flag.StringVar(&c.electionCandidateBinaryVersion, "election-candidate-binary-version", "", "Binary version advertised by the member LeaseCandidate, usually the version of the running release")
+	flag.StringVar(&c.electionCandidateEmulationVersion, "election-candidate-emulation-version", "", "Emulation version advertised by the member LeaseCandidate, defaults to election-candidate-binary-version")
+	flag.IntVar(&c.electionCandidatePriority, "election-candidate-priority", 0, "Priority advertised by the member LeaseCandidate, as the prometheus-elector.io/candidate-priority annotation")
+
+	flag.StringVar(&c.globalLeaseName, "global-lease-name", "", "Name of a global lease the local leader competes for, only the global leader applies the leader configuration. Empty disables the hierarchical election")
+	flag.StringVar(&c.globalLeaseNamespace, "global-lease-namespace", "", "Namespace of the global lease")
+	flag.StringVar(&c.globalKubeConfigPath, "global-kubeconfig", "", "Path to a kubeconfig of the cluster hosting the global lease")
+	flag.StringVar(&c.globalClusterName, "global-cluster-name", "", "Name of the local cluster, unique among the clusters competing for the global lease. The member takes part to the global election as <cluster name>/<member ID>")
+
+	flag.DurationVar(&c.splitBrainCheckPeriod, "split-brain-check-period", 0, "How often the leader status of the peers is polled to detect more than one member claiming the leadership, 0 disables it")
+	flag.DurationVar(&c.splitBrainThreshold, "split-brain-threshold", 10*time.Second, "How long more than one member has to claim the leadership before a split-brain is reported")
+	flag.BoolVar(&c.splitBrainStepDown, "split-brain-step-down", false, "Make a leader step down when a split-brain is reported, unless it holds the highest term")
+
+	flag.Var(&c.fitnessQueries, "fitness-query", "PromQL expression evaluated on the local Prometheus to score the fitness of the member between 0 and 1, the fitness is the lowest score of the queries. Can be repeated")
+	flag.StringVar(&c.fitnessPrometheusURL, "fitness-prometheus-url", "", "Base URL of the local Prometheus HTTP API used to evaluate the fitness queries")
+	flag.DurationVar(&c.fitnessPeriod, "fitness-period", 30*time.Second, "How often the fitness of the member is computed")
+	flag.Float64Var(&c.fitnessThreshold, "fitness-threshold", 0, "Fitness below which a leader hands over the leadership to the fittest peer above it, 0 disables it")
+
+	flag.IntVar(&c.electionApplyFailureThreshold, "election-apply-failure-threshold", 0, "How many times in a row the leader configuration can fail to be written or notified before the leader steps down, 0 disables it")
+	flag.DurationVar(&c.electionApplyFailureCooldown, "election-apply-failure-cooldown", 5*time.Minute, "How long a leader that stepped down because it couldn't apply its configuration refuses to take the lease back, unless another member acquires it")
+
+	flag.DurationVar(&c.electionMemberHeartbeatPeriod, "election-member-heartbeat-period", 10*time.Second, "How often the member publishes its heartbeat listed by the members API endpoint, 0 disables it")
+	flag.DurationVar(&c.electionMemberStaleTimeout, "election-member-stale-timeout", 30*time.Second, "How long after its last heartbeat a member is considered stale")
+	flag.BoolVar(&c.electionConfigGating, "election-config-gating", false, "Prevent a member from acquiring the leadership while its source configuration is older than the one of another member taking part to the election, for instance during a ConfigMap rollout")
+	flag.StringVar(&c.electionConfigGatingConfigMap, "election-config-gating-configmap", "", "Name of the ConfigMap publishing the source configuration in the lease namespace, under the name of the config file as key. Its prometheus-elector.io/config-generation annotation orders the source configurations for the configuration gating")
+
+	flag.IntVar(&c.electionHistorySize, "election-history-size", 100, "How many election events are kept in the history served by the API, 0 disables it")
+	flag.StringVar(&c.electionHistoryPath, "election-history-path", "", "Path of a file to persist the election history to, so it survives restarts")
+
+	flag.DurationVar(&c.electionMinLeadershipDuration, "election-min-leadership-duration", 0, "Minimum duration a leader keeps the leadership before leaving the election when Prometheus becomes unhealthy")
+	flag.DurationVar(&c.electionRejoinCooldown, "election-rejoin-cooldown", 0, "Delay to wait before joining the election again after leaving it, doubles every time the member leaves the election again within election-max-rejoin-cooldown")
+	flag.DurationVar(&c.electionMaxRejoinCooldown, "election-max-rejoin-cooldown", 5*time.Minute, "Maximum delay to wait before joining the election again after leaving it")
 
 	flag.StringVar(&c.kubeConfigPath, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 
 	flag.StringVar(&c.configPath, "config", "", "Path of the prometheus-elector configuration")
 	flag.StringVar(&c.outputPath, "output", "", "Path to write the Prometheus configuration")
+	flag.BoolVar(&c.configTemplatesEnabled, "config-templates-enabled", false, "Render the string values of the leader section containing {{ as Go templates, exposing the slot and the term of the member")
 
 	flag.StringVar(&c.readinessHTTPURL, "readiness-http-url", "", "URL to the Prometheus ready endpoint")
 	flag.DurationVar(&c.readinessPollPeriod, "readiness-poll-period", 5*time.Second, "Poll period prometheus readiness check")
@@ -203,15 +656,42 @@ func (c *cliConfig) setupFlags() {
 	flag.DurationVar(&c.notifyRetryDelay, "notify-retry-delay", 10*time.Second, "Delay between two notify retries.")
 	flag.DurationVar(&c.notifyTimeout, "notify-timeout", 2*time.Second, "HTTP timeout for notify retries.")
 
+	flag.DurationVar(&c.resyncPeriod, "resync-period", time.Minute, "How often the written configuration is checked for drift and repaired, 0 disables it")
+	flag.StringVar(&c.resyncMetricsURL, "resync-metrics-url", "", "URL to the Prometheus metrics endpoint, used to check that Prometheus loaded the last configuration written")
+
 	flag.StringVar(&c.apiListenAddr, "api-listen-address", ":9095", "HTTP listen address for the API.")
 	flag.DurationVar(&c.apiShutdownGraceDelay, "api-shutdown-grace-delay", 15*time.Second, "Grace delay to apply when shutting down the API server")
 	flag.BoolVar(&c.apiProxyEnabled, "api-proxy-enabled", false, "Turn on leader proxy on the API")
 	flag.UintVar(&c.apiProxyPrometheusLocalPort, "api-proxy-prometheus-local-port", 9090, "Listening port of the local prometheus instance")
 	flag.UintVar(&c.apiProxyPrometheusRemotePort, "api-proxy-prometheus-remote-port", 9090, "Listening port of any remote prometheus instance")
 	flag.StringVar(&c.apiProxyPrometheusServiceName, "api-proxy-prometheus-service-name", "", "Name of the statefulset headless service")
+	flag.BoolVar(&c.apiLeaderTransferEnabled, "api-leader-transfer-enabled", false, "Turn on the leadership step-down and transfer endpoints on the API")
+	flag.BoolVar(&c.apiCordonEnabled, "api-cordon-enabled", false, "Turn on the member cordon and uncordon endpoints on the API")
+	flag.StringVar(&c.leaderServiceName, "leader-service-name", "", "Name of a selector-less service to point at the leader by maintaining its EndpointSlice, empty disables it")
+	flag.UintVar(&c.leaderServicePort, "leader-service-port", 9090, "Port of the leader published in the EndpointSlice of the leader service")
+	flag.StringVar(&c.leaderServicePortName, "leader-service-port-name", "", "Name of the port published in the EndpointSlice, must match the name of the leader service port")
+	flag.BoolVar(&c.podRoleEnabled, "pod-role-enabled", false, "Reflect the role of the member on its pod with the prometheus-elector/role label and the prometheus-elector.io/leader condition")
+	flag.Var(&c.hookExecCommands, "hook-exec", "Command to run on every role change, with the role, term, leader and member ID passed as environment variables. Can be repeated")
+	flag.Var(&c.hookWebhookURLs, "hook-webhook-url", "URL to post a JSON payload describing every role change to. Can be repeated")
+	flag.DurationVar(&c.hookTimeout, "hook-timeout", 10*time.Second, "Timeout of a transition hook run")
+	flag.IntVar(&c.hookRetryMaxAttempts, "hook-retry-max-attempts", 3, "How many attempts to run a transition hook")
+	flag.DurationVar(&c.hookRetryDelay, "hook-retry-delay", time.Second, "Delay between two attempts to run a transition hook")
+	flag.BoolVar(&c.eventsEnabled, "events-enabled", false, "Emit Kubernetes events on the member pod and the lease")
+	flag.IntVar(&c.eventsBurst, "events-burst", 25, "Maximum amount of events emitted in a burst for a given object")
+	flag.DurationVar(&c.eventsRefillPeriod, "events-refill-period", 5*time.Minute, "Period after which one more event can be emitted for a given object once the burst is exhausted")
 	flag.BoolVar(&c.runtimeMetrics, "runtime-metrics", false, "Export go runtime metrics")
 }
 
+// stringsFlag is a flag that can be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, ",") }
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
 // this is how the http standard library validates the method in NewRequestWithContext.
 func validHTTPMethod(method string) bool {
 	return len(method) > 0 && strings.IndexFunc(method, func(r rune) bool {
@@ -9,6 +9,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/jlevesy/prometheus-elector/config"
 )
 
 func TestCliConfig_ValidateInitConfig(t *testing.T) {
@@ -31,6 +33,13 @@ func TestCliConfig_ValidateInitConfig(t *testing.T) {
 			},
 			wantErr: errors.New("missing output flag"),
 		},
+		{
+			desc: "observer doesn't require any path",
+			cfg: cliConfig{
+				electionMode: electionModeObserver,
+			},
+			wantErr: nil,
+		},
 		{
 			desc: "ok",
 			cfg: cliConfig{
@@ -51,8 +60,13 @@ func TestCliConfig_ValidateInitConfig(t *testing.T) {
 }
 
 var goodConfig = cliConfig{
+	electionMode:                electionModeMember,
 	leaseName:                   "lease",
 	leaseNamespace:              "namespace",
+	leaderTransferTimeout:       30 * time.Second,
+	electionSlots:               1,
+	electionHandoverPolicy:      config.HandoverImmediate,
+	electionMaxRejoinCooldown:   5 * time.Minute,
 	memberID:                    "bloupi",
 	notifyHTTPURL:               "http://reload.com",
 	notifyHTTPMethod:            http.MethodPost,
@@ -70,8 +84,13 @@ var goodConfig = cliConfig{
 }
 
 var goodConfigWithProxy = cliConfig{
+	electionMode:                  electionModeMember,
 	leaseName:                     "lease",
 	leaseNamespace:                "namespace",
+	leaderTransferTimeout:         30 * time.Second,
+	electionSlots:                 1,
+	electionHandoverPolicy:        config.HandoverImmediate,
+	electionMaxRejoinCooldown:     5 * time.Minute,
 	memberID:                      "bloupi",
 	notifyHTTPURL:                 "http://reload.com",
 	notifyHTTPMethod:              http.MethodPost,
@@ -135,6 +154,511 @@ func TestCliConfig_ValidateRuntimeConfig(t *testing.T) {
 			},
 			wantErr: errors.New("missing lease-namespace flag"),
 		},
+		{
+			desc:       "invalid leader transfer timeout",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.leaderTransferTimeout = 0
+			},
+			wantErr: errors.New("invalid leader-transfer-timeout, should be >= 1"),
+		},
+		{
+			desc:       "invalid election min leadership duration",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMinLeadershipDuration = -time.Second
+			},
+			wantErr: errors.New("invalid election-min-leadership-duration, should be >= 0"),
+		},
+		{
+			desc:       "invalid election rejoin cooldown",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionRejoinCooldown = -time.Second
+			},
+			wantErr: errors.New("invalid election-rejoin-cooldown, should be >= 0"),
+		},
+		{
+			desc:       "invalid election max rejoin cooldown",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionRejoinCooldown = 10 * time.Minute
+			},
+			wantErr: errors.New("invalid election-max-rejoin-cooldown, should be >= election-rejoin-cooldown"),
+		},
+		{
+			desc:       "invalid election-member-heartbeat-period",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMemberHeartbeatPeriod = -time.Second
+			},
+			wantErr: errors.New("invalid election-member-heartbeat-period, should be >= 0"),
+		},
+		{
+			desc:       "election-member-stale-timeout lower than election-member-heartbeat-period",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMemberHeartbeatPeriod = time.Minute
+				c.electionMemberStaleTimeout = time.Second
+			},
+			wantErr: errors.New("invalid election-member-stale-timeout, should be >= election-member-heartbeat-period"),
+		},
+		{
+			desc:       "invalid events-burst",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.eventsEnabled = true
+				c.eventsBurst = 0
+				c.eventsRefillPeriod = time.Minute
+			},
+			wantErr: errors.New("invalid events-burst, should be >= 1"),
+		},
+		{
+			desc:       "invalid events-refill-period",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.eventsEnabled = true
+				c.eventsBurst = 25
+			},
+			wantErr: errors.New("invalid events-refill-period, should be > 0"),
+		},
+		{
+			desc:       "invalid election-mode",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMode = "bozo"
+			},
+			wantErr: errors.New("invalid election-mode, should be member or observer"),
+		},
+		{
+			desc:       "observer doesn't require notify, readiness or healthcheck settings",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMode = electionModeObserver
+				c.notifyHTTPURL = ""
+				c.readinessPollPeriod = 0
+				c.healthcheckPeriod = 0
+			},
+			wantMemberID: "bloupi",
+			wantErr:      nil,
+		},
+		{
+			desc:       "observer with leader transfer",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMode = electionModeObserver
+				c.apiLeaderTransferEnabled = true
+				c.electionMemberHeartbeatPeriod = 10 * time.Second
+				c.electionMemberStaleTimeout = 30 * time.Second
+			},
+			wantErr: errors.New("api-leader-transfer-enabled is not supported in observer mode"),
+		},
+		{
+			desc:       "observer with pod role",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMode = electionModeObserver
+				c.podRoleEnabled = true
+			},
+			wantErr: errors.New("pod-role-enabled is not supported in observer mode"),
+		},
+		{
+			desc:       "observer with hooks",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMode = electionModeObserver
+				c.hookWebhookURLs = stringsFlag{"http://hooks.example.com"}
+			},
+			wantErr: errors.New("hook-webhook-url is not supported in observer mode"),
+		},
+		{
+			desc:       "invalid election-slots",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionSlots = 0
+			},
+			wantErr: errors.New("invalid election-slots, should be >= 1"),
+		},
+		{
+			desc:       "election-slots without config templates",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionSlots = 3
+			},
+			wantErr: errors.New("election-slots > 1 requires config-templates-enabled, used to shard the leader configuration by slot"),
+		},
+		{
+			desc:       "election-slots with leader transfer",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionSlots = 3
+				c.configTemplatesEnabled = true
+				c.apiLeaderTransferEnabled = true
+			},
+			wantErr: errors.New("api-leader-transfer-enabled and api-cordon-enabled are not supported when election-slots > 1"),
+		},
+		{
+			desc:       "invalid election-handover-timeout",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionHandoverTimeout = -time.Second
+			},
+			wantErr: errors.New("invalid election-handover-timeout, should be >= 0"),
+		},
+		{
+			desc:       "election-slots with handover",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionSlots = 3
+				c.configTemplatesEnabled = true
+				c.electionHandoverTimeout = time.Minute
+			},
+			wantErr: errors.New("election-handover-timeout is not supported when election-slots > 1"),
+		},
+		{
+			desc:       "invalid election-handover-policy",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionHandoverPolicy = "eventually"
+			},
+			wantErr: errors.New("invalid election-handover-policy, should be immediate, overlap or quiet"),
+		},
+		{
+			desc:       "overlap handover policy without delay",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionHandoverPolicy = config.HandoverOverlap
+			},
+			wantErr: errors.New("invalid election-handover-delay, should be > 0 when election-handover-policy is overlap or quiet"),
+		},
+		{
+			desc:       "quiet handover policy",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionHandoverPolicy = config.HandoverQuiet
+				c.electionHandoverDelay = 30 * time.Second
+			},
+			wantMemberID: "bloupi",
+		},
+		{
+			desc:       "observer with handover policy",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMode = electionModeObserver
+				c.electionHandoverPolicy = config.HandoverOverlap
+				c.electionHandoverDelay = 30 * time.Second
+			},
+			wantErr: errors.New("election-handover-policy is not supported in observer mode"),
+		},
+		{
+			desc:       "invalid resync-period",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.resyncPeriod = -time.Second
+			},
+			wantErr: errors.New("invalid resync-period, should be >= 0"),
+		},
+		{
+			desc:       "resync-metrics-url without resync",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.resyncMetricsURL = "http://localhost:9090/metrics"
+			},
+			wantErr: errors.New("resync-metrics-url requires resync-period > 0"),
+		},
+		{
+			desc:       "invalid election-out-of-zone-acquire-delay",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionOutOfZoneAcquireDelay = -time.Second
+			},
+			wantErr: errors.New("invalid election-out-of-zone-acquire-delay, should be >= 0"),
+		},
+		{
+			desc:       "election-slots with preferred zone",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionSlots = 3
+				c.configTemplatesEnabled = true
+				c.electionPreferredZone = "zone-a"
+			},
+			wantErr: errors.New("election-preferred-zone is not supported when election-slots > 1"),
+		},
+		{
+			desc:       "election-coordinated without binary version",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionCoordinated = true
+				c.electionCoordinatedStrategy = "OldestEmulationVersion"
+			},
+			wantErr: errors.New("missing election-candidate-binary-version flag, required when election-coordinated is set"),
+		},
+		{
+			desc:       "election-coordinated with election-slots",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionCoordinated = true
+				c.electionCoordinatedStrategy = "OldestEmulationVersion"
+				c.electionCandidateBinaryVersion = "1.2.0"
+				c.electionSlots = 3
+				c.configTemplatesEnabled = true
+			},
+			wantErr: errors.New("election-coordinated is not supported when election-slots > 1"),
+		},
+		{
+			desc:       "election-coordinated with leader transfer",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionCoordinated = true
+				c.electionCoordinatedStrategy = "OldestEmulationVersion"
+				c.electionCandidateBinaryVersion = "1.2.0"
+				c.apiLeaderTransferEnabled = true
+			},
+			wantErr: errors.New("election-preferred-zone, api-leader-transfer-enabled and api-cordon-enabled are not supported when election-coordinated is set"),
+		},
+		{
+			desc:       "observer with election-coordinated",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMode = electionModeObserver
+				c.electionCoordinated = true
+				c.electionCoordinatedStrategy = "OldestEmulationVersion"
+				c.electionCandidateBinaryVersion = "1.2.0"
+			},
+			wantErr: errors.New("election-coordinated is not supported in observer mode"),
+		},
+		{
+			desc:       "global-lease-name without global-kubeconfig",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.globalLeaseName = "prometheus-global"
+				c.globalLeaseNamespace = "monitoring"
+				c.globalClusterName = "east"
+			},
+			wantErr: errors.New("missing global-kubeconfig flag, required when global-lease-name is set"),
+		},
+		{
+			desc:       "global-lease-name without global-cluster-name",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.globalLeaseName = "prometheus-global"
+				c.globalLeaseNamespace = "monitoring"
+				c.globalKubeConfigPath = "/etc/global/kubeconfig"
+			},
+			wantErr: errors.New("missing global-cluster-name flag, required when global-lease-name is set"),
+		},
+		{
+			desc:       "global-lease-name with election-slots",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.globalLeaseName = "prometheus-global"
+				c.globalLeaseNamespace = "monitoring"
+				c.globalKubeConfigPath = "/etc/global/kubeconfig"
+				c.globalClusterName = "east"
+				c.electionSlots = 3
+				c.configTemplatesEnabled = true
+			},
+			wantErr: errors.New("election-slots > 1, election-coordinated and election-handover-timeout are not supported when global-lease-name is set"),
+		},
+		{
+			desc:       "observer with global-lease-name",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMode = electionModeObserver
+				c.globalLeaseName = "prometheus-global"
+				c.globalLeaseNamespace = "monitoring"
+				c.globalKubeConfigPath = "/etc/global/kubeconfig"
+				c.globalClusterName = "east"
+			},
+			wantErr: errors.New("global-lease-name is not supported in observer mode"),
+		},
+		{
+			desc:       "split-brain-check-period without service name",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.splitBrainCheckPeriod = 10 * time.Second
+				c.electionMemberHeartbeatPeriod = 10 * time.Second
+			},
+			wantErr: errors.New("split-brain-check-period requires api-proxy-prometheus-service-name, used to reach the peers"),
+		},
+		{
+			desc:       "split-brain-check-period without heartbeat",
+			baseConfig: goodConfigWithProxy,
+			patchConfig: func(c *cliConfig) {
+				c.splitBrainCheckPeriod = 10 * time.Second
+				c.electionMemberHeartbeatPeriod = 0
+			},
+			wantErr: errors.New("split-brain-check-period requires election-member-heartbeat-period > 0, used to discover the peers"),
+		},
+		{
+			desc:       "leader transfer without heartbeat",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.apiLeaderTransferEnabled = true
+				c.electionMemberHeartbeatPeriod = 0
+			},
+			wantErr: errors.New("api-leader-transfer-enabled requires election-member-heartbeat-period > 0, used to check the successor"),
+		},
+		{
+			desc:       "election-config-gating without heartbeat",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionConfigGating = true
+				c.electionMemberHeartbeatPeriod = 0
+			},
+			wantErr: errors.New("election-config-gating requires election-member-heartbeat-period > 0, used to compare the configurations"),
+		},
+		{
+			desc:       "election-config-gating without configmap",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionConfigGating = true
+				c.electionMemberHeartbeatPeriod = 10 * time.Second
+				c.electionMemberStaleTimeout = 30 * time.Second
+			},
+			wantErr: errors.New("election-config-gating requires election-config-gating-configmap, used to order the configurations"),
+		},
+		{
+			desc:       "observer with election-config-gating",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMode = electionModeObserver
+				c.electionConfigGating = true
+				c.electionConfigGatingConfigMap = "prometheus-elector-config"
+				c.electionMemberHeartbeatPeriod = 10 * time.Second
+				c.electionMemberStaleTimeout = 30 * time.Second
+			},
+			wantErr: errors.New("election-config-gating is not supported in observer mode"),
+		},
+		{
+			desc:       "invalid election-apply-failure-threshold",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionApplyFailureThreshold = -1
+			},
+			wantErr: errors.New("invalid election-apply-failure-threshold, should be >= 0"),
+		},
+		{
+			desc:       "invalid election-apply-failure-cooldown",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionApplyFailureThreshold = 3
+				c.electionApplyFailureCooldown = 0
+			},
+			wantErr: errors.New("invalid election-apply-failure-cooldown, should be > 0"),
+		},
+		{
+			desc:       "election-apply-failure-threshold with election-slots",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionApplyFailureThreshold = 3
+				c.electionApplyFailureCooldown = time.Minute
+				c.electionSlots = 3
+				c.configTemplatesEnabled = true
+			},
+			wantErr: errors.New("election-apply-failure-threshold is not supported when election-slots > 1 or election-coordinated is set"),
+		},
+		{
+			desc:       "fitness-query without fitness-prometheus-url",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.fitnessQueries = stringsFlag{"up"}
+				c.fitnessPeriod = 30 * time.Second
+			},
+			wantErr: errors.New("missing fitness-prometheus-url flag, required when fitness-query is set"),
+		},
+		{
+			desc:       "invalid fitness-threshold",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.fitnessQueries = stringsFlag{"up"}
+				c.fitnessPrometheusURL = "http://localhost:9090"
+				c.fitnessPeriod = 30 * time.Second
+				c.fitnessThreshold = 1.5
+			},
+			wantErr: errors.New("invalid fitness-threshold, should be between 0 and 1"),
+		},
+		{
+			desc:       "fitness-threshold without heartbeat",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.fitnessQueries = stringsFlag{"up"}
+				c.fitnessPrometheusURL = "http://localhost:9090"
+				c.fitnessPeriod = 30 * time.Second
+				c.fitnessThreshold = 0.5
+				c.electionMemberHeartbeatPeriod = 0
+			},
+			wantErr: errors.New("fitness-threshold requires election-member-heartbeat-period > 0, used to share the fitness of the members"),
+		},
+		{
+			desc:       "observer with fitness-query",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionMode = electionModeObserver
+				c.fitnessQueries = stringsFlag{"up"}
+				c.fitnessPrometheusURL = "http://localhost:9090"
+				c.fitnessPeriod = 30 * time.Second
+			},
+			wantErr: errors.New("fitness-query is not supported in observer mode"),
+		},
+		{
+			desc:       "split-brain-check-period with invalid api-listen-address",
+			baseConfig: goodConfigWithProxy,
+			patchConfig: func(c *cliConfig) {
+				c.splitBrainCheckPeriod = 10 * time.Second
+				c.electionMemberHeartbeatPeriod = 10 * time.Second
+				c.electionMemberStaleTimeout = 30 * time.Second
+				c.apiListenAddr = "localhost"
+			},
+			wantErr: errors.New("invalid api-listen-address, should be [host]:port when split-brain-check-period > 0"),
+		},
+		{
+			desc:       "invalid leader-service-port",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.leaderServiceName = "prometheus-leader"
+				c.leaderServicePort = 0
+			},
+			wantErr: errors.New("invalid leader-service-port, should be > 0 and <= 65535"),
+		},
+		{
+			desc:       "leader-service-name with election-slots",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.leaderServiceName = "prometheus-leader"
+				c.leaderServicePort = 9090
+				c.electionSlots = 3
+				c.configTemplatesEnabled = true
+			},
+			wantErr: errors.New("leader-service-name is not supported when election-slots > 1"),
+		},
+		{
+			desc:       "invalid hook-timeout",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.hookExecCommands = stringsFlag{"/bin/flush-cache"}
+				c.hookTimeout = 0
+			},
+			wantErr: errors.New("invalid hook-timeout, should be > 0"),
+		},
+		{
+			desc:       "invalid hook-retry-max-attempts",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.hookWebhookURLs = stringsFlag{"http://hooks.example.com"}
+				c.hookTimeout = time.Second
+				c.hookRetryMaxAttempts = 0
+			},
+			wantErr: errors.New("invalid hook-retry-max-attempts, should be >= 1"),
+		},
+		{
+			desc:       "invalid election-history-size",
+			baseConfig: goodConfig,
+			patchConfig: func(c *cliConfig) {
+				c.electionHistorySize = -1
+			},
+			wantErr: errors.New("invalid election-history-size, should be >= 0"),
+		},
 		{
 			desc:       "missing lease notify-http-url",
 			baseConfig: goodConfig,
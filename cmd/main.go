@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"golang.org/x/sync/errgroup"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/leaderelection"
@@ -19,14 +24,24 @@ import (
 	"github.com/jlevesy/prometheus-elector/api"
 	"github.com/jlevesy/prometheus-elector/config"
 	"github.com/jlevesy/prometheus-elector/election"
+	"github.com/jlevesy/prometheus-elector/endpoints"
+	"github.com/jlevesy/prometheus-elector/events"
+	"github.com/jlevesy/prometheus-elector/fitness"
 	"github.com/jlevesy/prometheus-elector/health"
+	"github.com/jlevesy/prometheus-elector/hooks"
 	"github.com/jlevesy/prometheus-elector/notifier"
+	"github.com/jlevesy/prometheus-elector/podrole"
 	"github.com/jlevesy/prometheus-elector/readiness"
+	"github.com/jlevesy/prometheus-elector/reconcile"
+	"github.com/jlevesy/prometheus-elector/splitbrain"
 	"github.com/jlevesy/prometheus-elector/watcher"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
+// podRoleTimeout bounds the update of the pod role.
+const podRoleTimeout = 5 * time.Second
+
 func main() {
 	os.Exit(run())
 }
@@ -49,9 +64,14 @@ func run() int {
 		return 1
 	}
 
-	reconciller := config.NewReconciller(cfg.configPath, cfg.outputPath)
+	if cfg.observer() && !cfg.init {
+		return runObserver(ctx, &cfg)
+	}
+
+	reconciller := config.NewReconciller(cfg.configPath, cfg.outputPath, cfg.configTemplatesEnabled)
+	initial := initialState(ctx, &cfg)
 
-	if err := reconciller.Reconcile(ctx, false); err != nil {
+	if err := reconciller.Reconcile(ctx, initial); err != nil {
 		klog.ErrorS(err, "Can't perform an initial sync")
 		return 1
 	}
@@ -66,12 +86,31 @@ func run() int {
 		return 1
 	}
 
-	metricsRegistry := prometheus.NewRegistry()
-	if cfg.runtimeMetrics {
-		metricsRegistry.MustRegister(collectors.NewBuildInfoCollector())
-		metricsRegistry.MustRegister(collectors.NewGoCollector(
-			collectors.WithGoCollectorRuntimeMetrics(collectors.MetricsAll),
-		))
+	metricsRegistry := newMetricsRegistry(&cfg)
+
+	k8sClient, err := newKubernetesClient(&cfg)
+	if err != nil {
+		klog.ErrorS(err, "Can't build the Kubernetes client")
+		return 1
+	}
+
+	var recorder events.Recorder = events.NoopRecorder{}
+
+	if cfg.eventsEnabled {
+		k8sRecorder := events.NewKubernetes(
+			ctx,
+			k8sClient,
+			events.Config{
+				Namespace:    cfg.leaseNamespace,
+				PodName:      cfg.memberID,
+				LeaseName:    cfg.leaseName,
+				Burst:        cfg.eventsBurst,
+				RefillPeriod: cfg.eventsRefillPeriod,
+			},
+		)
+		defer k8sRecorder.Shutdown()
+
+		recorder = k8sRecorder
 	}
 
 	notifier := notifier.WithRetry(
@@ -85,80 +124,447 @@ func run() int {
 		),
 		cfg.notifyRetryMaxAttempts,
 		cfg.notifyRetryDelay,
+		recorder,
 	)
 
-	k8sConfig, err := clientcmd.BuildConfigFromFlags("", cfg.kubeConfigPath)
-	if err != nil {
-		klog.ErrorS(err, "Unable to build kube client configuration")
-		return 1
+	var zone string
+
+	if cfg.electionPreferredZone != "" {
+		zone, err = election.LookupZone(ctx, k8sClient, cfg.leaseNamespace, cfg.memberID)
+		if err != nil {
+			klog.ErrorS(err, "Unable to lookup the zone of the member, considering it out of the preferred zone")
+		}
+
+		klog.InfoS("Topology aware election enabled", "zone", zone, "preferredZone", cfg.electionPreferredZone)
+	}
+
+	if cfg.electionCoordinated {
+		if err := election.CheckCoordinatedElection(k8sClient.Discovery()); err != nil {
+			klog.ErrorS(err, "Can't run the coordinated election")
+			return 1
+		}
+	}
+
+	var history *election.History
+
+	if cfg.electionHistorySize > 0 {
+		history, err = election.NewHistory(cfg.electionHistorySize, cfg.electionHistoryPath)
+		if err != nil {
+			klog.ErrorS(err, "Can't set up the election history")
+			return 1
+		}
+	}
+
+	var (
+		electionConfig = election.Config{
+			LeaseName:       cfg.leaseName,
+			LeaseNamespace:  cfg.leaseNamespace,
+			LeaseDuration:   cfg.leaseDuration,
+			RenewDeadline:   cfg.leaseRenewDeadline,
+			RetryPeriod:     cfg.leaseRetryPeriod,
+			MemberID:        cfg.memberID,
+			TransferTimeout: cfg.leaderTransferTimeout,
+
+			Zone:                  zone,
+			PreferredZone:         cfg.electionPreferredZone,
+			OutOfZoneAcquireDelay: cfg.electionOutOfZoneAcquireDelay,
+
+			Coordinated: cfg.electionCoordinated,
+			Candidate: election.Candidate{
+				BinaryVersion:    cfg.electionCandidateBinaryVersion,
+				EmulationVersion: cfg.electionCandidateEmulationVersion,
+				Priority:         cfg.electionCandidatePriority,
+				Strategy:         coordinationv1.CoordinatedLeaseStrategy(cfg.electionCoordinatedStrategy),
+			},
+
+			HeartbeatPeriod:    cfg.electionMemberHeartbeatPeriod,
+			MemberStaleTimeout: cfg.electionMemberStaleTimeout,
+			ConfigHash:         reconciller.ConfigHash,
+			SourceHash:         reconciller.SourceHash,
+			ConfigGating:       cfg.electionConfigGating,
+			SourceConfigMap:    cfg.electionConfigGatingConfigMap,
+			SourceConfigMapKey: filepath.Base(cfg.configPath),
+
+			History: history,
+		}
+
+		elector            electionMember
+		singleElector      *election.Elector
+		hierarchy          *election.Hierarchy
+		electionController election.Controller
+		// The election deciding whether the member applies the leader configuration.
+		leadership election.Status
+
+		fitnessScorer *fitness.Scorer
+
+		// Set when the member released the lease because it is shutting down, and is waiting for the next leader.
+		handingOver atomic.Bool
+		// Set while the member starts with the leader configuration and didn't resume its leadership yet.
+		resuming atomic.Bool
+	)
+
+	resuming.Store(initial.Leader)
+
+	if len(cfg.fitnessQueries) > 0 {
+		// The scorer is set up with the elector, before the heartbeat starts.
+		electionConfig.Fitness = func() (float64, bool) { return fitnessScorer.Score() }
+	}
+
+	var leaderEndpoints *endpoints.Publisher
+
+	if cfg.leaderServiceName != "" {
+		leaderEndpoints = endpoints.NewPublisher(
+			endpoints.Config{
+				ServiceName: cfg.leaderServiceName,
+				Namespace:   cfg.leaseNamespace,
+				PodName:     cfg.memberID,
+				PortName:    cfg.leaderServicePortName,
+				Port:        int32(cfg.leaderServicePort),
+				// Failed publications are retried as often as the lease is renewed.
+				ResyncPeriod: cfg.leaseRetryPeriod,
+			},
+			k8sClient,
+		)
+	}
+
+	setPodRole := func(context.Context, bool) {}
+
+	if cfg.podRoleEnabled {
+		podRole := podrole.NewPatcher(
+			podrole.Config{Namespace: cfg.leaseNamespace, PodName: cfg.memberID},
+			k8sClient,
+		)
+
+		if err := podRole.Preflight(ctx); err != nil {
+			klog.ErrorS(err, "Can't reflect the role on the pod")
+			return 1
+		}
+
+		setPodRole = func(ctx context.Context, leader bool) {
+			if err := podRole.SetRole(ctx, leader); err != nil {
+				klog.ErrorS(err, "Failed to update the pod role")
+			}
+		}
+
+		setPodRole(ctx, false)
+
+		// Registered before leaving the election, so it runs once the member stopped leading.
+		defer func() {
+			revertCtx, cancel := context.WithTimeout(context.Background(), podRoleTimeout)
+			defer cancel()
+
+			if err := podRole.Revert(revertCtx); err != nil {
+				klog.ErrorS(err, "Unable to revert the pod role")
+			}
+		}()
+	}
+
+	transitionHooks := make([]hooks.Hook, 0, len(cfg.hookExecCommands)+len(cfg.hookWebhookURLs))
+
+	for _, command := range cfg.hookExecCommands {
+		hook, err := hooks.NewExec(strings.Fields(command), cfg.hookTimeout)
+		if err != nil {
+			klog.ErrorS(err, "Can't set up the exec hook", "command", command)
+			return 1
+		}
+
+		transitionHooks = append(transitionHooks, hook)
+	}
+
+	for _, url := range cfg.hookWebhookURLs {
+		hook, err := hooks.NewWebhook(url, cfg.hookTimeout)
+		if err != nil {
+			klog.ErrorS(err, "Can't set up the webhook")
+			return 1
+		}
+
+		transitionHooks = append(transitionHooks, hook)
+	}
+
+	hookRunner := hooks.NewRunner(transitionHooks, cfg.hookRetryMaxAttempts, cfg.hookRetryDelay, metricsRegistry)
+
+	// The role changes are reflected in order off the election callbacks, which run while the elector is locked.
+	// Each one is bounded by its own timeout so it still completes once the shutdown began.
+	transitions := hooks.NewQueue(
+		podRoleTimeout + time.Duration(len(transitionHooks)*cfg.hookRetryMaxAttempts)*(cfg.hookTimeout+cfg.hookRetryDelay),
+	)
+
+	// Registered before leaving the election, so the last role change is reflected before the pod role is reverted.
+	defer transitions.Close()
+
+	reflectRole := func(state config.State) {
+		evt := hooks.Event{Role: state.Role(), Term: leadership.GetTerm(), Leader: leadership.GetLeader(), MemberID: cfg.memberID}
+
+		transitions.Submit(func(ctx context.Context) {
+			setPodRole(ctx, state.Leader)
+			hookRunner.Run(ctx, evt)
+		})
 	}
 
-	k8sClient, err := kubernetes.NewForConfig(k8sConfig)
+	// The queue serializes the reconciliations requested by the election and the watcher, and repairs drifts.
+	var reloadChecker reconcile.ReloadChecker
+
+	if cfg.resyncMetricsURL != "" {
+		reloadChecker = reconcile.NewHTTPReloadChecker(cfg.resyncMetricsURL, cfg.notifyTimeout)
+	}
+
+	reconcileQueue := reconcile.NewQueue(
+		reconcile.Config{
+			ResyncPeriod:           cfg.resyncPeriod,
+			ReloadChecker:          reloadChecker,
+			LeaderFailureThreshold: cfg.electionApplyFailureThreshold,
+			LeaderRetryDelay:       cfg.notifyRetryDelay,
+			OnLeaderFailures: func(state config.State, err error) {
+				recorder.ElectionEventf(
+					corev1.EventTypeWarning,
+					events.ReasonLeaderConfigFailed,
+					"Unable to apply the leader configuration of term %d: %s, stepping down for %s",
+					state.Term,
+					err,
+					cfg.electionApplyFailureCooldown,
+				)
+
+				// Stepping down goes through the election callbacks, which submit to the queue.
+				go func() {
+					err := singleElector.StepDownFor(grpCtx, cfg.electionApplyFailureCooldown, "unable to apply the leader configuration")
+					if err != nil && !errors.Is(err, election.ErrNotLeader) && !errors.Is(err, election.ErrNotRunning) {
+						klog.ErrorS(err, "Unable to step down after failing to apply the leader configuration")
+					}
+				}()
+			},
+		},
+		reconciller,
+		notifier,
+		recorder,
+		initial,
+		func(state config.State) {
+			switch {
+			case !state.Leader:
+			case hierarchy != nil:
+				hierarchy.MarkApplied(state.Term)
+			case singleElector != nil:
+				singleElector.MarkApplied(state.Term)
+			}
+		},
+		metricsRegistry,
+	)
+
+	// The handover delays the configuration changes according to the policy, hooks still run on every role change.
+	handover, err := config.NewHandover(
+		cfg.electionHandoverPolicy,
+		cfg.electionHandoverDelay,
+		initial,
+		reconcileQueue.Submit,
+	)
 	if err != nil {
-		klog.ErrorS(err, "Can't build the Kubernetes client")
+		klog.ErrorS(err, "Can't setup the handover")
 		return 1
 	}
 
-	elector, err := election.New(
-		election.Config{
-			LeaseName:      cfg.leaseName,
-			LeaseNamespace: cfg.leaseNamespace,
-			LeaseDuration:  cfg.leaseDuration,
-			RenewDeadline:  cfg.leaseRenewDeadline,
-			RetryPeriod:    cfg.leaseRetryPeriod,
-			MemberID:       cfg.memberID,
-		},
-		k8sClient,
-		leaderelection.LeaderCallbacks{
+	if cfg.electionSlots > 1 {
+		slots, err := election.NewSlots(
+			electionConfig,
+			cfg.electionSlots,
+			k8sClient,
+			election.SlotCallbacks{
+				OnStartedLeading: func(_ context.Context, slot int) {
+					resuming.Store(false)
+
+					term := leadership.GetTerm()
+
+					klog.InfoS("Leading, applying leader configuration.", "slot", slot, "term", term)
+					recorder.ElectionEventf(corev1.EventTypeNormal, events.ReasonStartedLeading, "Started leading slot %d, term %d", slot, term)
+
+					state := config.State{Leader: true, Slot: slot, Slots: cfg.electionSlots, Term: term}
+
+					handover.Transition(state)
+					reflectRole(state)
+				},
+				OnStoppedLeading: func(slot int) {
+					klog.InfoS("Stopped leading, applying follower configuration.", "slot", slot)
+					recorder.ElectionEventf(corev1.EventTypeNormal, events.ReasonStoppedLeading, "Stopped leading slot %d", slot)
+
+					state := config.State{Leader: false, Slots: cfg.electionSlots}
+
+					handover.Transition(state)
+					reflectRole(state)
+				},
+				OnNewLeader: func(slot int, identity string) {
+					// Another member took over the held slot before the member resumed it.
+					if slot == initial.Slot && identity != cfg.memberID && resuming.CompareAndSwap(true, false) {
+						klog.InfoS("Unable to resume the slot, applying follower configuration.", "slot", slot, "leader", identity)
+						handover.Transition(config.State{Leader: false, Slots: cfg.electionSlots})
+					}
+				},
+			},
+			metricsRegistry,
+		)
+		if err != nil {
+			klog.ErrorS(err, "Can't setup the election")
+			return 1
+		}
+
+		elector = slots
+	} else {
+		callbacks := leaderelection.LeaderCallbacks{
 			OnStartedLeading: func(ctx context.Context) {
-				klog.Info("Leading, applying leader configuration.")
+				resuming.Store(false)
 
-				if err := reconciller.Reconcile(ctx, true); err != nil {
-					klog.ErrorS(err, "Failed to reconcile configurations")
-					return
-				}
+				term := leadership.GetTerm()
 
-				if err := notifier.Notify(ctx); err != nil {
-					klog.ErrorS(err, "Failed to notify prometheus")
-					return
+				klog.InfoS("Leading, applying leader configuration.", "term", term)
+				recorder.ElectionEventf(corev1.EventTypeNormal, events.ReasonStartedLeading, "Started leading, term %d", term)
+
+				if leaderEndpoints != nil {
+					if err := leaderEndpoints.Publish(ctx, term); err != nil {
+						klog.ErrorS(err, "Failed to publish the leader endpoints")
+					}
 				}
+
+				state := config.State{Leader: true, Slots: 1, Term: term}
+
+				handover.Transition(state)
+				reflectRole(state)
 			},
 			OnStoppedLeading: func() {
-				klog.Info("Stopped leading, applying follower configuration.")
+				recorder.ElectionEventf(corev1.EventTypeNormal, events.ReasonStoppedLeading, "Stopped leading")
 
-				if err := reconciller.Reconcile(ctx, false); err != nil {
-					klog.ErrorS(err, "Failed to reconcile configurations")
-					return
+				if leaderEndpoints != nil {
+					clearCtx, cancel := context.WithTimeout(context.Background(), cfg.leaseRenewDeadline)
+					if err := leaderEndpoints.Clear(clearCtx); err != nil {
+						klog.ErrorS(err, "Failed to clear the leader endpoints")
+					}
+					cancel()
 				}
 
-				if err := notifier.Notify(ctx); err != nil {
-					klog.ErrorS(err, "Failed to notify prometheus")
+				state := config.State{Leader: false, Slots: 1}
+
+				// Prometheus keeps writing with the leader configuration until the next leader applied its own.
+				if cfg.electionHandoverTimeout > 0 && grpCtx.Err() != nil {
+					klog.Info("Stopped leading while shutting down, keeping the leader configuration.")
+					handingOver.Store(true)
+					reflectRole(state)
+
 					return
 				}
+
+				klog.Info("Stopped leading, applying follower configuration.")
+
+				handover.Transition(state)
+				reflectRole(state)
 			},
-		},
-		metricsRegistry,
-	)
-	if err != nil {
-		klog.ErrorS(err, "Can't setup the election")
-		return 1
+			OnNewLeader: func(identity string) {
+				// Another member took over before the member resumed its leadership.
+				if identity != cfg.memberID && resuming.CompareAndSwap(true, false) {
+					klog.InfoS("Unable to resume the leadership, applying follower configuration.", "leader", identity)
+					handover.Transition(config.State{Leader: false, Slots: 1})
+				}
+			},
+		}
+
+		if cfg.globalLeaseName != "" {
+			hierarchy, err = newHierarchy(&cfg, electionConfig, k8sClient, callbacks, metricsRegistry)
+		} else {
+			singleElector, err = election.New(electionConfig, k8sClient, callbacks, metricsRegistry)
+		}
+		if err != nil {
+			klog.ErrorS(err, "Can't setup the election")
+			return 1
+		}
+
+		if hierarchy != nil {
+			// Only the global leader applies the leader configuration.
+			singleElector = hierarchy.Elector
+			elector = hierarchy
+			leadership = hierarchy.Global()
+		} else {
+			elector = singleElector
+		}
+
+		electionController = singleElector
 	}
 
-	// Always leave the election.
-	defer func() {
+	if leadership == nil {
+		leadership = elector.Status()
+	}
+
+	var splitBrainDetector *splitbrain.Detector
+
+	if cfg.splitBrainCheckPeriod > 0 {
+		// Validated with the configuration.
+		apiPort, _ := cfg.apiPort()
+
+		splitBrainDetector = splitbrain.NewDetector(
+			splitbrain.Config{
+				PeerURL:   splitbrain.HeadlessServicePeers(cfg.apiProxyPrometheusServiceName, apiPort),
+				Period:    cfg.splitBrainCheckPeriod,
+				Timeout:   cfg.healthcheckTimeout,
+				Threshold: cfg.splitBrainThreshold,
+				StepDown:  cfg.splitBrainStepDown,
+			},
+			cfg.memberID,
+			singleElector.Status(),
+			singleElector,
+			singleElector.StepDown,
+			recorder,
+			metricsRegistry,
+		)
+	}
+
+	if len(cfg.fitnessQueries) > 0 {
+		fitnessScorer = fitness.NewScorer(
+			fitness.Config{
+				PrometheusURL: cfg.fitnessPrometheusURL,
+				Queries:       cfg.fitnessQueries,
+				Period:        cfg.fitnessPeriod,
+				Timeout:       cfg.healthcheckTimeout,
+				Threshold:     cfg.fitnessThreshold,
+			},
+			cfg.memberID,
+			singleElector.Status(),
+			singleElector,
+			singleElector.Transfer,
+			recorder,
+			metricsRegistry,
+		)
+	}
+
+	leaveElection := func() {
 		stopCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
 
+		wasLeading := !elector.LeadingSince().IsZero()
+
 		klog.Info("Graceful shutdown, leaving the election")
 
-		if err := elector.Stop(stopCtx); err != nil && errors.Is(err, election.ErrNotRunning) {
+		if err := elector.Stop(stopCtx); err != nil && !errors.Is(err, election.ErrNotRunning) {
 			klog.ErrorS(err, "Unable to leave the election")
 		}
 
 		klog.Info("Graceful shutdown, left the election")
-	}()
 
-	watcher, err := watcher.New(filepath.Dir(cfg.configPath), reconciller, notifier, elector.Status())
+		// Apply the role change delayed by the handover policy before the configuration stops being reconciled.
+		handover.Flush(stopCtx)
+
+		// The lease might have been released as soon as the shutdown began.
+		releasedOnShutdown := handingOver.Swap(false)
+
+		if cfg.electionHandoverTimeout == 0 || singleElector == nil || (!wasLeading && !releasedOnShutdown) {
+			return
+		}
+
+		waitCtx, cancelWait := context.WithTimeout(context.Background(), cfg.electionHandoverTimeout)
+		defer cancelWait()
+
+		if err := singleElector.WaitForSuccessor(waitCtx); err != nil {
+			klog.ErrorS(err, "The next leader didn't apply its configuration in time")
+		}
+	}
+
+	// Always leave the election.
+	defer leaveElection()
+
+	watcher, err := watcher.New(filepath.Dir(cfg.configPath), reconcileQueue)
 	if err != nil {
 		klog.ErrorS(err, "Can't create the watcher")
 		return 1
@@ -173,8 +579,11 @@ func run() int {
 			PrometheusLocalPort:   cfg.apiProxyPrometheusLocalPort,
 			PrometheusRemotePort:  cfg.apiProxyPrometheusRemotePort,
 			PrometheusServiceName: cfg.apiProxyPrometheusServiceName,
+			EnableLeaderTransfer:  cfg.apiLeaderTransferEnabled,
+			EnableCordon:          cfg.apiCordonEnabled,
 		},
 		elector.Status(),
+		electionController,
 		metricsRegistry,
 	)
 
@@ -193,6 +602,16 @@ func run() int {
 		)
 	}
 
+	electionGuard := election.NewGuard(
+		election.GuardConfig{
+			MinLeadershipDuration: cfg.electionMinLeadershipDuration,
+			RejoinCooldown:        cfg.electionRejoinCooldown,
+			MaxRejoinCooldown:     cfg.electionMaxRejoinCooldown,
+		},
+		elector,
+		metricsRegistry,
+	)
+
 	var healthChecker health.Checker = health.NoopChecker{}
 
 	if cfg.healthcheckHTTPURL != "" {
@@ -207,17 +626,26 @@ func run() int {
 			health.CallbacksFuncs{
 				OnHealthyFunc: func() error {
 					klog.Info("Prometheus is healthy, joining the election")
-					err := elector.Start(grpCtx)
+					elector.SetHealthy(true)
+
+					err := electionGuard.Join(grpCtx)
 					if errors.Is(err, election.ErrAlreadyRunning) {
 						klog.Info("Already joined the election, ignoring.")
 						return nil
 					}
 
+					if errors.Is(err, election.ErrCordoned) {
+						klog.Info("Member is cordoned, not joining the election.")
+						return nil
+					}
+
 					return err
 				},
 				OnUnHealthyFunc: func() error {
 					klog.Info("Prometheus is unhealthy, leaving the election")
-					err := elector.Stop(grpCtx)
+					elector.SetHealthy(false)
+
+					err := electionGuard.Leave(grpCtx)
 					if errors.Is(err, election.ErrNotRunning) {
 						klog.Info("Already left the election, ignoring.")
 						return nil
@@ -226,6 +654,7 @@ func run() int {
 					return err
 				},
 			},
+			recorder,
 		)
 
 	}
@@ -244,6 +673,11 @@ func run() int {
 			return nil
 		}
 
+		if errors.Is(err, election.ErrCordoned) {
+			klog.Info("Member is cordoned, not joining the election.")
+			return nil
+		}
+
 		return err
 	})
 
@@ -258,7 +692,93 @@ func run() int {
 		return healthChecker.Check(grpCtx)
 	})
 
+	grp.Go(func() error { return elector.RunHeartbeat(grpCtx) })
 	grp.Go(func() error { return watcher.Watch(grpCtx) })
+
+	if splitBrainDetector != nil {
+		grp.Go(func() error { return splitBrainDetector.Run(grpCtx) })
+	}
+
+	if fitnessScorer != nil {
+		grp.Go(func() error { return fitnessScorer.Run(grpCtx) })
+	}
+
+	if leaderEndpoints != nil {
+		grp.Go(func() error { return leaderEndpoints.Run(grpCtx) })
+	}
+
+	// The API keeps serving until the member left the election, exposing the handover.
+	apiCtx, cancelAPI := context.WithCancel(context.Background())
+	defer cancelAPI()
+
+	grp.Go(func() error {
+		<-grpCtx.Done()
+		defer cancelAPI()
+
+		leaveElection()
+
+		return nil
+	})
+	grp.Go(func() error { return apiServer.Serve(apiCtx) })
+	// Keeps reconciling until the member left the election, applying the follower configuration.
+	grp.Go(func() error { return reconcileQueue.Run(apiCtx) })
+
+	if err := grp.Wait(); err != nil {
+		klog.ErrorS(err, "prometheus-elector has reported an error while running")
+		return 1
+	}
+
+	klog.Info("prometheus-elector is gracefully stopping")
+	return 0
+}
+
+// runObserver follows the election without competing, only serving the API.
+// There's no local Prometheus to manage: readiness, health and notifications are skipped.
+func runObserver(ctx context.Context, cfg *cliConfig) int {
+	if err := cfg.validateRuntimeConfig(); err != nil {
+		klog.ErrorS(err, "Invalid election config")
+		return 1
+	}
+
+	metricsRegistry := newMetricsRegistry(cfg)
+
+	k8sClient, err := newKubernetesClient(cfg)
+	if err != nil {
+		klog.ErrorS(err, "Can't build the Kubernetes client")
+		return 1
+	}
+
+	observer := election.NewObserver(
+		election.Config{
+			LeaseName:          cfg.leaseName,
+			LeaseNamespace:     cfg.leaseNamespace,
+			MemberID:           cfg.memberID,
+			MemberStaleTimeout: cfg.electionMemberStaleTimeout,
+		},
+		k8sClient,
+	)
+
+	apiServer, err := api.NewServer(
+		api.Config{
+			ListenAddress:         cfg.apiListenAddr,
+			ShutdownGraceDelay:    cfg.apiShutdownGraceDelay,
+			EnableLeaderProxy:     cfg.apiProxyEnabled,
+			PrometheusLocalPort:   cfg.apiProxyPrometheusLocalPort,
+			PrometheusRemotePort:  cfg.apiProxyPrometheusRemotePort,
+			PrometheusServiceName: cfg.apiProxyPrometheusServiceName,
+		},
+		observer.Status(),
+		nil,
+		metricsRegistry,
+	)
+	if err != nil {
+		klog.ErrorS(err, "Can't set up the API server")
+		return 1
+	}
+
+	grp, grpCtx := errgroup.WithContext(ctx)
+
+	grp.Go(func() error { return observer.Run(grpCtx) })
 	grp.Go(func() error { return apiServer.Serve(grpCtx) })
 
 	if err := grp.Wait(); err != nil {
@@ -269,3 +789,131 @@ func run() int {
 	klog.Info("prometheus-elector is gracefully stopping")
 	return 0
 }
+
+func newMetricsRegistry(cfg *cliConfig) *prometheus.Registry {
+	metricsRegistry := prometheus.NewRegistry()
+	if cfg.runtimeMetrics {
+		metricsRegistry.MustRegister(collectors.NewBuildInfoCollector())
+		metricsRegistry.MustRegister(collectors.NewGoCollector(
+			collectors.WithGoCollectorRuntimeMetrics(collectors.MetricsAll),
+		))
+	}
+
+	return metricsRegistry
+}
+
+// initialState returns the state to apply before joining the election: the leader state if the member
+// still holds the lease, for instance because its container restarted, the follower state otherwise.
+// Only the single leader election is resumed, the multi slot election always starts as a follower.
+func initialState(ctx context.Context, cfg *cliConfig) config.State {
+	follower := config.State{Leader: false, Slots: cfg.electionSlots}
+
+	if cfg.observer() || cfg.globalLeaseName != "" || cfg.leaseName == "" || cfg.leaseNamespace == "" {
+		return follower
+	}
+
+	memberID := cfg.memberID
+	if memberID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return follower
+		}
+
+		memberID = hostname
+	}
+
+	k8sClient, err := newKubernetesClient(cfg)
+	if err != nil {
+		klog.ErrorS(err, "Unable to check if the member holds the lease, starting as a follower")
+		return follower
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, cfg.leaseRenewDeadline)
+	defer cancel()
+
+	electionConfig := election.Config{LeaseName: cfg.leaseName, LeaseNamespace: cfg.leaseNamespace, MemberID: memberID}
+
+	if cfg.electionSlots > 1 {
+		slot, term, held, err := election.LookupHeldSlot(lookupCtx, k8sClient, electionConfig, cfg.electionSlots)
+		if err != nil {
+			klog.ErrorS(err, "Unable to check if the member holds a slot, starting as a follower")
+			return follower
+		}
+
+		if !held {
+			return follower
+		}
+
+		klog.InfoS("Member still holds a slot, starting with the leader configuration", "slot", slot, "term", term)
+
+		return config.State{Leader: true, Slot: slot, Slots: cfg.electionSlots, Term: term}
+	}
+
+	term, held, err := election.LookupHeldTerm(lookupCtx, k8sClient, electionConfig)
+	if err != nil {
+		klog.ErrorS(err, "Unable to check if the member holds the lease, starting as a follower")
+		return follower
+	}
+
+	if !held {
+		return follower
+	}
+
+	klog.InfoS("Member still holds the lease, starting with the leader configuration", "term", term)
+
+	return config.State{Leader: true, Slots: 1, Term: term}
+}
+
+func newKubernetesClient(cfg *cliConfig) (kubernetes.Interface, error) {
+	k8sConfig, err := clientcmd.BuildConfigFromFlags("", cfg.kubeConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build kube client configuration: %w", err)
+	}
+
+	return kubernetes.NewForConfig(k8sConfig)
+}
+
+// newHierarchy builds a hierarchical election, where the local leader competes for the global lease
+// hosted by the cluster of the global kubeconfig.
+func newHierarchy(
+	cfg *cliConfig,
+	localConfig election.Config,
+	k8sClient kubernetes.Interface,
+	callbacks leaderelection.LeaderCallbacks,
+	reg prometheus.Registerer,
+) (*election.Hierarchy, error) {
+	globalK8sConfig, err := clientcmd.BuildConfigFromFlags("", cfg.globalKubeConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build global kube client configuration: %w", err)
+	}
+
+	globalK8sClient, err := kubernetes.NewForConfig(globalK8sConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build global kube client: %w", err)
+	}
+
+	globalConfig := election.Config{
+		LeaseName:       cfg.globalLeaseName,
+		LeaseNamespace:  cfg.globalLeaseNamespace,
+		LeaseDuration:   cfg.leaseDuration,
+		RenewDeadline:   cfg.leaseRenewDeadline,
+		RetryPeriod:     cfg.leaseRetryPeriod,
+		MemberID:        cfg.globalMemberID(),
+		TransferTimeout: cfg.leaderTransferTimeout,
+		History:         localConfig.History,
+	}
+
+	klog.InfoS("Hierarchical election enabled", "globalLease", cfg.globalLeaseName, "globalMemberID", globalConfig.MemberID)
+
+	return election.NewHierarchy(localConfig, k8sClient, globalConfig, globalK8sClient, callbacks, reg)
+}
+
+// electionMember is either a single leader election or a multi slot election.
+type electionMember interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+	LeadingSince() time.Time
+	Status() election.Status
+	RunHeartbeat(ctx context.Context) error
+	SetHealthy(healthy bool)
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"os"
 
@@ -10,6 +12,9 @@ import (
 type config struct {
 	Follower map[string]any `yaml:"follower"`
 	Leader   map[string]any `yaml:"leader"`
+
+	// sourceHash is the SHA256 of the source file the configuration was loaded from.
+	sourceHash string
 }
 
 func loadConfiguration(path string) (*config, error) {
@@ -28,14 +33,8 @@ func loadConfiguration(path string) (*config, error) {
 		return nil, errors.New("missing follower configuration")
 	}
 
-	return &cfg, nil
-}
+	hash := sha256.Sum256(fileBytes)
+	cfg.sourceHash = hex.EncodeToString(hash[:])
 
-func writeConfiguration(path string, cfg map[string]any) error {
-	b, err := yaml.Marshal(cfg)
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(path, b, 0600)
+	return &cfg, nil
 }
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// HandoverImmediate applies every role change right away.
+	HandoverImmediate = "immediate"
+	// HandoverOverlap keeps the leader configuration for a while after losing the leadership,
+	// for backends deduplicating the samples written by both leaders.
+	HandoverOverlap = "overlap"
+	// HandoverQuiet waits for a while before applying the leader configuration after acquiring the leadership,
+	// for backends that would count twice the samples written by both leaders.
+	HandoverQuiet = "quiet"
+)
+
+// Handover applies the role changes of the member according to a handover policy.
+// A role change happening while another one is delayed supersedes it: if the leadership bounces
+// back during the delay, the pending change is dropped and the configuration never changes role.
+type Handover struct {
+	policy string
+	delay  time.Duration
+	apply  func(state State)
+
+	mu      sync.Mutex
+	current State
+	pending *pendingTransition
+}
+
+// pendingTransition is a role change waiting for the handover delay, done is closed once it is applied or dropped.
+type pendingTransition struct {
+	timer *time.Timer
+	state State
+	done  chan struct{}
+}
+
+func NewHandover(policy string, delay time.Duration, initial State, apply func(state State)) (*Handover, error) {
+	switch policy {
+	case HandoverImmediate, HandoverOverlap, HandoverQuiet:
+	default:
+		return nil, fmt.Errorf("unknown handover policy %q", policy)
+	}
+
+	return &Handover{
+		policy:  policy,
+		delay:   delay,
+		apply:   apply,
+		current: initial,
+	}, nil
+}
+
+// Transition applies the given state, now or after the delay if the policy requires it.
+func (h *Handover) Transition(state State) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pending != nil {
+		klog.InfoS("Role changed again during the handover delay, dropping the pending change", "policy", h.policy, "role", state.Role())
+
+		h.pending.timer.Stop()
+		close(h.pending.done)
+		h.pending = nil
+	}
+
+	if state == h.current {
+		return
+	}
+
+	if !h.delayed(state) {
+		h.applyLocked(state)
+		return
+	}
+
+	klog.InfoS("Delaying the configuration change", "policy", h.policy, "role", state.Role(), "delay", h.delay)
+
+	pending := &pendingTransition{state: state, done: make(chan struct{})}
+
+	pending.timer = time.AfterFunc(h.delay, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		// Superseded by another transition, or flushed.
+		if h.pending != pending {
+			return
+		}
+
+		h.applyPendingLocked()
+	})
+
+	h.pending = pending
+}
+
+// Flush waits for the delayed role change, if any, to be applied, and applies it right away if the given context is done first.
+// It is called on shutdown, so that the last role change is applied before the configuration stops being reconciled.
+func (h *Handover) Flush(ctx context.Context) {
+	h.mu.Lock()
+	pending := h.pending
+	h.mu.Unlock()
+
+	if pending == nil {
+		return
+	}
+
+	select {
+	case <-pending.done:
+		return
+	case <-ctx.Done():
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pending != pending {
+		return
+	}
+
+	klog.InfoS("Shutting down during the handover delay, applying the pending change", "policy", h.policy, "role", pending.state.Role())
+
+	pending.timer.Stop()
+	h.applyPendingLocked()
+}
+
+// State returns the last applied state.
+func (h *Handover) State() State {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.current
+}
+
+func (h *Handover) delayed(state State) bool {
+	switch h.policy {
+	case HandoverOverlap:
+		return h.current.Leader && !state.Leader
+	case HandoverQuiet:
+		return !h.current.Leader && state.Leader
+	default:
+		return false
+	}
+}
+
+// applyPendingLocked must be called with h.mu held and a pending transition.
+func (h *Handover) applyPendingLocked() {
+	pending := h.pending
+
+	h.pending = nil
+	close(pending.done)
+	h.applyLocked(pending.state)
+}
+
+// applyLocked must be called with h.mu held.
+func (h *Handover) applyLocked(state State) {
+	h.current = state
+	h.apply(state)
+}
@@ -0,0 +1,206 @@
+package config_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jlevesy/prometheus-elector/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const handoverDelay = 50 * time.Millisecond
+
+var (
+	follower = config.State{Leader: false, Slots: 1}
+	leader   = config.State{Leader: true, Slots: 1, Term: 1}
+	leader2  = config.State{Leader: true, Slots: 1, Term: 3}
+)
+
+type appliedStates struct {
+	mu     sync.Mutex
+	states []config.State
+}
+
+func (a *appliedStates) apply(state config.State) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.states = append(a.states, state)
+}
+
+func (a *appliedStates) get() []config.State {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]config.State(nil), a.states...)
+}
+
+func TestHandover(t *testing.T) {
+	for _, testCase := range []struct {
+		desc        string
+		policy      string
+		initial     config.State
+		transitions []config.State
+		// wantImmediate are the states applied right after the transitions.
+		wantImmediate []config.State
+		// wantEventually are the states applied once the delay elapsed.
+		wantEventually []config.State
+		wantState      config.State
+	}{
+		{
+			desc:           "immediate",
+			policy:         config.HandoverImmediate,
+			initial:        follower,
+			transitions:    []config.State{leader, follower},
+			wantImmediate:  []config.State{leader, follower},
+			wantEventually: []config.State{leader, follower},
+			wantState:      follower,
+		},
+		{
+			desc:           "skips unchanged states",
+			policy:         config.HandoverImmediate,
+			initial:        follower,
+			transitions:    []config.State{follower, leader, leader},
+			wantImmediate:  []config.State{leader},
+			wantEventually: []config.State{leader},
+			wantState:      leader,
+		},
+		{
+			desc:           "overlap applies the leader configuration immediately",
+			policy:         config.HandoverOverlap,
+			initial:        follower,
+			transitions:    []config.State{leader},
+			wantImmediate:  []config.State{leader},
+			wantEventually: []config.State{leader},
+			wantState:      leader,
+		},
+		{
+			desc:           "overlap delays the follower configuration",
+			policy:         config.HandoverOverlap,
+			initial:        leader,
+			transitions:    []config.State{follower},
+			wantImmediate:  nil,
+			wantEventually: []config.State{follower},
+			wantState:      follower,
+		},
+		{
+			desc:           "overlap drops the follower configuration when leading again",
+			policy:         config.HandoverOverlap,
+			initial:        leader,
+			transitions:    []config.State{follower, leader2},
+			wantImmediate:  []config.State{leader2},
+			wantEventually: []config.State{leader2},
+			wantState:      leader2,
+		},
+		{
+			desc:           "quiet applies the follower configuration immediately",
+			policy:         config.HandoverQuiet,
+			initial:        leader,
+			transitions:    []config.State{follower},
+			wantImmediate:  []config.State{follower},
+			wantEventually: []config.State{follower},
+			wantState:      follower,
+		},
+		{
+			desc:           "quiet delays the leader configuration",
+			policy:         config.HandoverQuiet,
+			initial:        follower,
+			transitions:    []config.State{leader},
+			wantImmediate:  nil,
+			wantEventually: []config.State{leader},
+			wantState:      leader,
+		},
+		{
+			desc:           "quiet drops the leader configuration when losing the leadership",
+			policy:         config.HandoverQuiet,
+			initial:        follower,
+			transitions:    []config.State{leader, follower},
+			wantImmediate:  nil,
+			wantEventually: nil,
+			wantState:      follower,
+		},
+		{
+			desc:           "quiet applies the last leader configuration when leadership bounces",
+			policy:         config.HandoverQuiet,
+			initial:        follower,
+			transitions:    []config.State{leader, follower, leader2},
+			wantImmediate:  nil,
+			wantEventually: []config.State{leader2},
+			wantState:      leader2,
+		},
+	} {
+		t.Run(testCase.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var applied appliedStates
+
+			handover, err := config.NewHandover(testCase.policy, handoverDelay, testCase.initial, applied.apply)
+			require.NoError(t, err)
+
+			for _, state := range testCase.transitions {
+				handover.Transition(state)
+			}
+
+			assert.Equal(t, testCase.wantImmediate, applied.get())
+
+			time.Sleep(3 * handoverDelay)
+
+			assert.Equal(t, testCase.wantEventually, applied.get())
+			assert.Equal(t, testCase.wantState, handover.State())
+		})
+	}
+}
+
+func TestHandover_Flush(t *testing.T) {
+	t.Run("applies the pending change once the delay elapsed", func(t *testing.T) {
+		var applied appliedStates
+
+		handover, err := config.NewHandover(config.HandoverOverlap, handoverDelay, leader, applied.apply)
+		require.NoError(t, err)
+
+		handover.Transition(follower)
+
+		start := time.Now()
+		handover.Flush(context.Background())
+
+		assert.GreaterOrEqual(t, time.Since(start), handoverDelay)
+		assert.Equal(t, []config.State{follower}, applied.get())
+	})
+
+	t.Run("applies the pending change right away when the context is done", func(t *testing.T) {
+		var applied appliedStates
+
+		handover, err := config.NewHandover(config.HandoverOverlap, time.Hour, leader, applied.apply)
+		require.NoError(t, err)
+
+		handover.Transition(follower)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		handover.Flush(ctx)
+
+		assert.Equal(t, []config.State{follower}, applied.get())
+		assert.Equal(t, follower, handover.State())
+	})
+
+	t.Run("returns right away without pending change", func(t *testing.T) {
+		var applied appliedStates
+
+		handover, err := config.NewHandover(config.HandoverOverlap, time.Hour, leader, applied.apply)
+		require.NoError(t, err)
+
+		handover.Flush(context.Background())
+
+		assert.Empty(t, applied.get())
+		assert.Equal(t, leader, handover.State())
+	})
+}
+
+func TestNewHandover_UnknownPolicy(t *testing.T) {
+	_, err := config.NewHandover("eventually", time.Second, follower, func(config.State) {})
+	require.Error(t, err)
+}
@@ -1,41 +1,138 @@
 package config
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/imdario/mergo"
+	"gopkg.in/yaml.v2"
 )
 
 type Reconciler struct {
 	sourcePath string
 	outputPath string
+	// templates enables the rendering of the Go templates of the leader section.
+	templates bool
+
+	configHashMu sync.RWMutex
+	configHash   string
+	sourceHash   string
+	writtenAt    time.Time
 }
 
-func NewReconciller(src, out string) *Reconciler {
+func NewReconciller(src, out string, templates bool) *Reconciler {
 	return &Reconciler{
 		sourcePath: src,
 		outputPath: out,
+		templates:  templates,
 	}
 }
 
-func (r *Reconciler) Reconcile(ctx context.Context, leader bool) error {
-	cfg, err := loadConfiguration(r.sourcePath)
+func (r *Reconciler) Reconcile(ctx context.Context, state State) error {
+	rendered, sourceHash, err := r.render(state)
 	if err != nil {
 		return err
 	}
 
+	if err := os.WriteFile(r.outputPath, rendered, 0600); err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(rendered)
+
+	r.configHashMu.Lock()
+	r.configHash = hex.EncodeToString(hash[:])
+	r.sourceHash = sourceHash
+	r.writtenAt = time.Now()
+	r.configHashMu.Unlock()
+
+	return nil
+}
+
+// Drifted tells if the output file differs from the configuration rendered for the given state.
+func (r *Reconciler) Drifted(state State) (bool, error) {
+	rendered, _, err := r.render(state)
+	if err != nil {
+		return false, err
+	}
+
+	written, err := os.ReadFile(r.outputPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return !bytes.Equal(rendered, written), nil
+}
+
+// WrittenAt returns when the output file has been written for the last time, zero if it hasn't been written yet.
+func (r *Reconciler) WrittenAt() time.Time {
+	r.configHashMu.RLock()
+	defer r.configHashMu.RUnlock()
+
+	return r.writtenAt
+}
+
+// render returns the configuration for the given state, and the hash of the source it has been rendered from.
+func (r *Reconciler) render(state State) ([]byte, string, error) {
+	cfg, err := loadConfiguration(r.sourcePath)
+	if err != nil {
+		return nil, "", err
+	}
+
 	targetCfg := cfg.Follower
 
-	if leader {
+	if state.Leader {
+		var leaderCfg any = cfg.Leader
+
+		if r.templates {
+			leaderCfg, err = renderTemplates(cfg.Leader, state)
+			if err != nil {
+				return nil, "", err
+			}
+		}
+
 		if err := mergo.Merge(
 			&targetCfg,
-			cfg.Leader,
+			leaderCfg,
 			mergo.WithOverride,
 			mergo.WithAppendSlice,
 		); err != nil {
-			return err
+			return nil, "", err
 		}
 	}
 
-	return writeConfiguration(r.outputPath, targetCfg)
+	rendered, err := yaml.Marshal(targetCfg)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return rendered, cfg.sourceHash, nil
+}
+
+// ConfigHash returns the SHA256 of the last configuration written, empty if none has been written yet.
+func (r *Reconciler) ConfigHash() string {
+	r.configHashMu.RLock()
+	defer r.configHashMu.RUnlock()
+
+	return r.configHash
+}
+
+// SourceHash returns the SHA256 of the source configuration the last configuration written was rendered from,
+// empty if none has been written yet. Unlike ConfigHash, it doesn't depend on the role of the member.
+func (r *Reconciler) SourceHash() string {
+	r.configHashMu.RLock()
+	defer r.configHashMu.RUnlock()
+
+	return r.sourceHash
 }
@@ -2,6 +2,8 @@ package config_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"os"
 	"path/filepath"
@@ -17,23 +19,57 @@ const fileName = "prometheus.yaml"
 func TestReconciler(t *testing.T) {
 	for _, testCase := range []struct {
 		desc           string
-		isLeader       bool
+		state          config.State
 		inputPath      string
+		templates      bool
 		wantError      error
 		wantResultPath string
 	}{
 		{
 			desc:           "follower",
 			inputPath:      "./testdata/config.yaml",
-			isLeader:       false,
+			state:          config.State{Leader: false, Slots: 1},
 			wantResultPath: "./testdata/follower_no_leader_result.yaml",
 		},
 		{
 			desc:           "leader",
 			inputPath:      "./testdata/config.yaml",
-			isLeader:       true,
+			state:          config.State{Leader: true, Slots: 1},
 			wantResultPath: "./testdata/leader_result.yaml",
 		},
+		{
+			desc:           "leader with slots",
+			templates:      true,
+			inputPath:      "./testdata/config_slots.yaml",
+			state:          config.State{Leader: true, Slot: 2, Slots: 3},
+			wantResultPath: "./testdata/leader_slot_result.yaml",
+		},
+		{
+			desc:           "leader with term",
+			templates:      true,
+			inputPath:      "./testdata/config_term.yaml",
+			state:          config.State{Leader: true, Slots: 1, Term: 7},
+			wantResultPath: "./testdata/leader_term_result.yaml",
+		},
+		{
+			desc:           "leader with templates",
+			templates:      true,
+			inputPath:      "./testdata/config_templates.yaml",
+			state:          config.State{Leader: true, Slots: 1, Term: 7},
+			wantResultPath: "./testdata/leader_templates_result.yaml",
+		},
+		{
+			desc:           "leader with templates disabled",
+			inputPath:      "./testdata/config_templates.yaml",
+			state:          config.State{Leader: true, Slots: 1, Term: 7},
+			wantResultPath: "./testdata/leader_templates_disabled_result.yaml",
+		},
+		{
+			desc:           "follower with slots",
+			inputPath:      "./testdata/config_slots.yaml",
+			state:          config.State{Leader: false, Slots: 3},
+			wantResultPath: "./testdata/follower_slot_result.yaml",
+		},
 		{
 			desc:           "no leader section",
 			inputPath:      "./testdata/config_no_leader.yaml",
@@ -53,10 +89,11 @@ func TestReconciler(t *testing.T) {
 				reconciler = config.NewReconciller(
 					testCase.inputPath,
 					outPath,
+					testCase.templates,
 				)
 			)
 
-			err := reconciler.Reconcile(ctx, testCase.isLeader)
+			err := reconciler.Reconcile(ctx, testCase.state)
 			if testCase.wantError != nil {
 				assert.Equal(t, testCase.wantError, err)
 				return
@@ -70,6 +107,49 @@ func TestReconciler(t *testing.T) {
 			require.NoError(t, err)
 
 			assert.Equal(t, string(wantBytes), string(gotBytes))
+
+			wantHash := sha256.Sum256(wantBytes)
+			assert.Equal(t, hex.EncodeToString(wantHash[:]), reconciler.ConfigHash())
+
+			sourceBytes, err := os.ReadFile(testCase.inputPath)
+			require.NoError(t, err)
+
+			wantSourceHash := sha256.Sum256(sourceBytes)
+			assert.Equal(t, hex.EncodeToString(wantSourceHash[:]), reconciler.SourceHash())
 		})
 	}
 }
+
+func TestReconciler_Drifted(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		outPath    = filepath.Join(t.TempDir(), fileName)
+		reconciler = config.NewReconciller("./testdata/config.yaml", outPath, false)
+		leader     = config.State{Leader: true, Slots: 1}
+		follower   = config.State{Leader: false, Slots: 1}
+	)
+
+	drifted, err := reconciler.Drifted(follower)
+	require.NoError(t, err)
+	assert.True(t, drifted, "missing output file")
+	assert.True(t, reconciler.WrittenAt().IsZero())
+
+	err = reconciler.Reconcile(ctx, follower)
+	require.NoError(t, err)
+	assert.False(t, reconciler.WrittenAt().IsZero())
+
+	drifted, err = reconciler.Drifted(follower)
+	require.NoError(t, err)
+	assert.False(t, drifted)
+
+	drifted, err = reconciler.Drifted(leader)
+	require.NoError(t, err)
+	assert.True(t, drifted, "rendered for another role")
+
+	err = os.WriteFile(outPath, []byte("global: {}\n"), 0600)
+	require.NoError(t, err)
+
+	drifted, err = reconciler.Drifted(follower)
+	require.NoError(t, err)
+	assert.True(t, drifted, "edited output file")
+}
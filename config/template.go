@@ -0,0 +1,137 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// State describes the position of the member in the election.
+// It is exposed to the leader configuration through Go templates, for instance `{{ .Slot }}`.
+type State struct {
+	// Leader is true when the member is leading.
+	Leader bool
+	// Slot is the index of the slot held by the member, only meaningful when leading.
+	Slot int
+	// Slots is the number of slots of the election, 1 for a single leader election.
+	Slots int
+	// Term is the term of the current leadership, incremented every time the lease is acquired.
+	Term int64
+}
+
+// Role returns either leader or follower.
+func (s State) Role() string {
+	if s.Leader {
+		return "leader"
+	}
+
+	return "follower"
+}
+
+// renderTemplates renders every string value containing a template action.
+// A value made of a single action keeps the type of its result, so that `modulus: "{{ .Slots }}"` renders to an integer,
+// any other value renders to a string.
+func renderTemplates(value any, state State) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+
+		return renderString(v, state)
+	case map[string]any:
+		for k, item := range v {
+			rendered, err := renderTemplates(item, state)
+			if err != nil {
+				return nil, err
+			}
+
+			v[k] = rendered
+		}
+
+		return v, nil
+	case map[any]any:
+		for k, item := range v {
+			rendered, err := renderTemplates(item, state)
+			if err != nil {
+				return nil, err
+			}
+
+			v[k] = rendered
+		}
+
+		return v, nil
+	case []any:
+		for i, item := range v {
+			rendered, err := renderTemplates(item, state)
+			if err != nil {
+				return nil, err
+			}
+
+			v[i] = rendered
+		}
+
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+func renderString(value string, state State) (any, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %w", value, err)
+	}
+
+	if action, ok := singleAction(tmpl); ok {
+		return evaluate(action, state)
+	}
+
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, state); err != nil {
+		return nil, fmt.Errorf("unable to render template %q: %w", value, err)
+	}
+
+	return buf.String(), nil
+}
+
+// singleAction returns the action the given template is made of, if it is made of a single action without any variable declaration.
+func singleAction(tmpl *template.Template) (*parse.ActionNode, bool) {
+	if tmpl.Tree == nil || len(tmpl.Tree.Root.Nodes) != 1 {
+		return nil, false
+	}
+
+	action, ok := tmpl.Tree.Root.Nodes[0].(*parse.ActionNode)
+	if !ok || len(action.Pipe.Decl) > 0 {
+		return nil, false
+	}
+
+	return action, true
+}
+
+// evaluate returns the result of the given action, instead of its string representation.
+func evaluate(action *parse.ActionNode, state State) (any, error) {
+	var result any
+
+	tmpl, err := template.New("").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"capture": func(v any) string {
+				result = v
+				return ""
+			},
+		}).
+		Parse("{{ capture (" + action.Pipe.String() + ") }}")
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %w", action.String(), err)
+	}
+
+	if err := tmpl.Execute(&bytes.Buffer{}, state); err != nil {
+		return nil, fmt.Errorf("unable to render template %q: %w", action.String(), err)
+	}
+
+	return result, nil
+}
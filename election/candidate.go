@@ -0,0 +1,154 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	coordinationv1beta1 "k8s.io/api/coordination/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	coordinationv1beta1client "k8s.io/client-go/kubernetes/typed/coordination/v1beta1"
+	"k8s.io/klog/v2"
+)
+
+// candidatePriorityAnnotation carries the priority of the member, for coordinators and strategies taking it into account.
+const candidatePriorityAnnotation = "prometheus-elector.io/candidate-priority"
+
+// candidateRenewPeriod is how often a candidate is renewed when the coordinator doesn't ping it.
+const candidateRenewPeriod = 5 * time.Minute
+
+// Candidate describes the member to the coordinator of a coordinated election.
+type Candidate struct {
+	BinaryVersion    string
+	EmulationVersion string
+	Priority         int
+	Strategy         coordinationv1.CoordinatedLeaseStrategy
+}
+
+// CheckCoordinatedElection makes sure that the cluster serves the LeaseCandidate API used by the coordinated election,
+// coordination.k8s.io/v1beta1 from Kubernetes 1.33. It is disabled by default and requires the CoordinatedLeaderElection feature gate.
+func CheckCoordinatedElection(discoveryClient discovery.DiscoveryInterface) error {
+	groupVersion := coordinationv1beta1.SchemeGroupVersion.String()
+
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(groupVersion)
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("%s isn't served, the coordinated election requires Kubernetes 1.33 or later with the CoordinatedLeaderElection feature gate and this API enabled", groupVersion)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to discover %s: %w", groupVersion, err)
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == "leasecandidates" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s doesn't serve leasecandidates, the coordinated election requires the CoordinatedLeaderElection feature gate", groupVersion)
+}
+
+// candidate maintains the LeaseCandidate of the member, named after the member ID,
+// and answers the pings of the coordinator.
+type candidate struct {
+	config     Config
+	candidates coordinationv1beta1client.LeaseCandidateInterface
+}
+
+func newCandidate(cfg Config, candidates coordinationv1beta1client.LeaseCandidateInterface) *candidate {
+	return &candidate{
+		config:     cfg,
+		candidates: candidates,
+	}
+}
+
+// run registers the member as a candidate every RetryPeriod until the given context is done, then removes the candidate.
+func (c *candidate) run(ctx context.Context) {
+	ticker := time.NewTicker(c.config.RetryPeriod)
+	defer ticker.Stop()
+
+	for {
+		if err := c.ensure(ctx); err != nil && ctx.Err() == nil {
+			klog.ErrorS(err, "Unable to register the member as a lease candidate")
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := c.remove(); err != nil {
+				klog.ErrorS(err, "Unable to remove the lease candidate")
+			}
+
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ensure creates the candidate, or renews it when the coordinator pinged it or when it is about to become stale.
+func (c *candidate) ensure(ctx context.Context) error {
+	now := metav1.NewMicroTime(time.Now())
+
+	existing, err := c.candidates.Get(ctx, c.config.MemberID, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = c.candidates.Create(ctx, c.leaseCandidate(now), metav1.CreateOptions{})
+
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	spec := existing.Spec
+	pinged := spec.PingTime != nil && (spec.RenewTime == nil || spec.PingTime.After(spec.RenewTime.Time))
+	stale := spec.RenewTime == nil || now.Sub(spec.RenewTime.Time) > candidateRenewPeriod
+
+	if !pinged && !stale {
+		return nil
+	}
+
+	existing.Spec.RenewTime = &now
+
+	_, err = c.candidates.Update(ctx, existing, metav1.UpdateOptions{})
+
+	return err
+}
+
+func (c *candidate) leaseCandidate(now metav1.MicroTime) *coordinationv1beta1.LeaseCandidate {
+	emulationVersion := c.config.Candidate.EmulationVersion
+	if emulationVersion == "" {
+		emulationVersion = c.config.Candidate.BinaryVersion
+	}
+
+	return &coordinationv1beta1.LeaseCandidate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        c.config.MemberID,
+			Namespace:   c.config.LeaseNamespace,
+			Labels:      map[string]string{electionLabel: c.config.LeaseName},
+			Annotations: map[string]string{candidatePriorityAnnotation: strconv.Itoa(c.config.Candidate.Priority)},
+		},
+		Spec: coordinationv1beta1.LeaseCandidateSpec{
+			LeaseName:        c.config.LeaseName,
+			RenewTime:        &now,
+			BinaryVersion:    c.config.Candidate.BinaryVersion,
+			EmulationVersion: emulationVersion,
+			Strategy:         c.config.Candidate.Strategy,
+		},
+	}
+}
+
+func (c *candidate) remove() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.RenewDeadline)
+	defer cancel()
+
+	err := c.candidates.Delete(ctx, c.config.MemberID, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("unable to delete the lease candidate: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package election_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/jlevesy/prometheus-elector/election"
+)
+
+func TestCheckCoordinatedElection(t *testing.T) {
+	kubeClient := kubefake.NewClientset()
+	discoveryClient := kubeClient.Discovery().(*fakediscovery.FakeDiscovery)
+
+	err := election.CheckCoordinatedElection(discoveryClient)
+	assert.EqualError(t, err, "coordination.k8s.io/v1beta1 isn't served, the coordinated election requires Kubernetes 1.33 or later with the CoordinatedLeaderElection feature gate and this API enabled")
+
+	discoveryClient.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "coordination.k8s.io/v1beta1"},
+	}
+
+	err = election.CheckCoordinatedElection(discoveryClient)
+	assert.EqualError(t, err, "coordination.k8s.io/v1beta1 doesn't serve leasecandidates, the coordinated election requires the CoordinatedLeaderElection feature gate")
+
+	discoveryClient.Resources[0].APIResources = []metav1.APIResource{{Name: "leasecandidates", Namespaced: true, Kind: "LeaseCandidate"}}
+
+	require.NoError(t, election.CheckCoordinatedElection(discoveryClient))
+}
+
+func TestElector_Coordinated(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		kubeClient  = kubefake.NewClientset()
+		electors    = make(map[string]*election.Elector)
+		started     = make(map[string]chan struct{})
+	)
+
+	defer cancel()
+
+	for memberID, version := range map[string]string{"foo": "1.2.0", "bar": "1.1.0", "biz": "1.2.0"} {
+		cfg := newTestConfig(memberID)
+		cfg.Coordinated = true
+		cfg.Candidate = election.Candidate{
+			BinaryVersion: version,
+			Priority:      10,
+			Strategy:      coordinationv1.OldestEmulationVersion,
+		}
+
+		elector, startedLeading, _ := newTestElectorWithConfig(t, kubeClient, cfg)
+		require.NoError(t, elector.Start(ctx))
+
+		electors[memberID] = elector
+		started[memberID] = startedLeading
+	}
+
+	go runTestCoordinator(ctx, t, kubeClient)
+
+	// The member running the oldest version is picked.
+	<-started["bar"]
+
+	assert.True(t, electors["bar"].Status().IsLeader())
+	assert.Eventually(
+		t,
+		func() bool { return electors["foo"].Status().GetLeader() == "bar" },
+		2*time.Second,
+		50*time.Millisecond,
+	)
+
+	firstTerm := electors["bar"].GetTerm()
+
+	candidate, err := kubeClient.CoordinationV1beta1().LeaseCandidates("test").Get(ctx, "bar", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "test", candidate.Spec.LeaseName)
+	assert.Equal(t, "1.1.0", candidate.Spec.EmulationVersion)
+	assert.Equal(t, "10", candidate.Annotations["prometheus-elector.io/candidate-priority"])
+
+	// Leaving the election removes the candidate, the coordinator picks another member.
+	require.NoError(t, electors["bar"].Stop(ctx))
+
+	_, err = kubeClient.CoordinationV1beta1().LeaseCandidates("test").Get(ctx, "bar", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	<-started["biz"]
+
+	assert.True(t, electors["biz"].Status().IsLeader())
+	assert.False(t, electors["foo"].Status().IsLeader())
+	assert.Equal(t, firstTerm+1, electors["biz"].GetTerm())
+
+	lease, err := kubeClient.CoordinationV1().Leases("test").Get(ctx, "test", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	require.NotNil(t, lease.Spec.Strategy)
+	assert.Equal(t, coordinationv1.OldestEmulationVersion, *lease.Spec.Strategy)
+}
+
+// runTestCoordinator simulates the coordinated leader election controller:
+// it assigns the lease to the candidate with the oldest emulation version, ties broken by name,
+// whenever the lease has no active holder.
+func runTestCoordinator(ctx context.Context, t *testing.T, kubeClient *kubefake.Clientset) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		candidates, err := kubeClient.CoordinationV1beta1().LeaseCandidates("test").List(ctx, metav1.ListOptions{})
+		if err != nil || len(candidates.Items) == 0 {
+			continue
+		}
+
+		sort.Slice(candidates.Items, func(i, j int) bool {
+			ci, cj := candidates.Items[i], candidates.Items[j]
+			if ci.Spec.EmulationVersion != cj.Spec.EmulationVersion {
+				return ci.Spec.EmulationVersion < cj.Spec.EmulationVersion
+			}
+
+			return ci.Name < cj.Name
+		})
+
+		var (
+			now      = metav1.NewMicroTime(time.Now())
+			electee  = candidates.Items[0].Name
+			strategy = coordinationv1.OldestEmulationVersion
+			duration = int32(1)
+		)
+
+		lease, err := kubeClient.CoordinationV1().Leases("test").Get(ctx, "test", metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			transitions := int32(1)
+
+			_, err = kubeClient.CoordinationV1().Leases("test").Create(
+				ctx,
+				&coordinationv1.Lease{
+					ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+					Spec: coordinationv1.LeaseSpec{
+						HolderIdentity:       &electee,
+						LeaseDurationSeconds: &duration,
+						AcquireTime:          &now,
+						RenewTime:            &now,
+						LeaseTransitions:     &transitions,
+						Strategy:             &strategy,
+					},
+				},
+				metav1.CreateOptions{},
+			)
+			assert.NoError(t, err)
+
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		spec := lease.Spec
+		if spec.HolderIdentity != nil && *spec.HolderIdentity != "" && spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds)*time.Second).After(now.Time) {
+			continue
+		}
+
+		transitions := *spec.LeaseTransitions + 1
+
+		lease.Spec.HolderIdentity = &electee
+		lease.Spec.LeaseDurationSeconds = &duration
+		lease.Spec.AcquireTime = &now
+		lease.Spec.RenewTime = &now
+		lease.Spec.LeaseTransitions = &transitions
+
+		_, _ = kubeClient.CoordinationV1().Leases("test").Update(ctx, lease, metav1.UpdateOptions{})
+	}
+}
@@ -0,0 +1,220 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// cordonAnnotationPrefix is the prefix of the lease annotation marking a member as cordoned.
+// The annotation is keyed by member to allow cordoning multiple members at once.
+const cordonAnnotationPrefix = "cordon.prometheus-elector.io/"
+
+func (e *Elector) Cordon(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.runCtx != nil && e.elector.IsLeader() {
+		// Writing the lease now would conflict with its renewals, the annotation is written once the elector stopped.
+		e.setCordoned(true)
+		e.cordonedByLease.Store(false)
+
+		klog.Info("Member cordoned, leaving the election")
+
+		leaveErr := e.leaveLocked(ctx, "cordoned")
+
+		if err := e.patchCordonAnnotation(ctx, true); err != nil {
+			return fmt.Errorf("unable to persist the cordon on the lease: %w", err)
+		}
+
+		return leaveErr
+	}
+
+	if err := e.patchCordonAnnotation(ctx, true); err != nil {
+		return err
+	}
+
+	e.setCordoned(true)
+	e.cordonedByLease.Store(false)
+
+	klog.Info("Member cordoned, leaving the election")
+
+	if e.runCtx == nil {
+		return nil
+	}
+
+	return e.leaveLocked(ctx, "cordoned")
+}
+
+func (e *Elector) Uncordon(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.patchCordonAnnotation(ctx, false); err != nil {
+		return err
+	}
+
+	e.setCordoned(false)
+	e.cordonedByLease.Store(false)
+
+	klog.Info("Member uncordoned")
+
+	if e.joinRequested && e.runCtx == nil && e.parentCtx != nil {
+		klog.Info("Joining the election again")
+		e.startLocked(e.parentCtx)
+	}
+
+	return nil
+}
+
+// leave stops the elector after the member got cordoned from the outside,
+// then watches the lease to join the election again once the cordon annotation is removed.
+func (e *Elector) leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.RenewDeadline)
+	defer cancel()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.runCtx == nil {
+		return
+	}
+
+	if err := e.leaveLocked(ctx, "cordoned through the lease"); err != nil {
+		klog.ErrorS(err, "Unable to leave the election")
+	}
+
+	go e.watchCordon(e.parentCtx)
+}
+
+// watchCordon polls the lease while the member is cordoned through the lease annotation,
+// and uncordons it once the annotation is removed.
+func (e *Elector) watchCordon(ctx context.Context) {
+	if !e.watchingCordon.CompareAndSwap(false, true) {
+		return
+	}
+
+	defer e.watchingCordon.Store(false)
+
+	ticker := time.NewTicker(e.config.RetryPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Uncordoned, or cordoned again through Cordon in the meantime.
+		if !e.cordonedByLease.Load() {
+			return
+		}
+
+		lease, err := e.leases().Get(ctx, e.config.LeaseName, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			klog.ErrorS(err, "Unable to get the lease to check the cordon annotation")
+			continue
+		default:
+			if _, cordoned := lease.Annotations[e.cordonAnnotation()]; cordoned {
+				continue
+			}
+		}
+
+		e.uncordonFromLease()
+
+		return
+	}
+}
+
+func (e *Elector) uncordonFromLease() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.cordonedByLease.Swap(false) {
+		return
+	}
+
+	e.setCordoned(false)
+
+	klog.Info("Cordon annotation removed from the lease, member uncordoned")
+
+	if e.joinRequested && e.runCtx == nil && e.parentCtx != nil {
+		klog.Info("Joining the election again")
+		e.startLocked(e.parentCtx)
+	}
+}
+
+// loadCordon must be called with e.mu held.
+func (e *Elector) loadCordon(ctx context.Context) error {
+	lease, err := e.leases().Get(ctx, e.config.LeaseName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		e.setCordoned(false)
+		e.cordonedByLease.Store(false)
+	case err != nil:
+		return err
+	default:
+		// The cordon is only stored in the annotation, even if it was placed through Cordon.
+		_, cordoned := lease.Annotations[e.cordonAnnotation()]
+		e.setCordoned(cordoned)
+		e.cordonedByLease.Store(cordoned)
+	}
+
+	e.cordonLoaded = true
+
+	return nil
+}
+
+func (e *Elector) patchCordonAnnotation(ctx context.Context, cordoned bool) error {
+	var value *string
+	if cordoned {
+		value = strPtr("true")
+	}
+
+	err := e.patchAnnotations(ctx, map[string]*string{e.cordonAnnotation(): value})
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if !cordoned {
+		return nil
+	}
+
+	// Nobody took the lease yet, create an empty one to hold the annotation.
+	_, err = e.leases().Create(
+		ctx,
+		&coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        e.config.LeaseName,
+				Namespace:   e.config.LeaseNamespace,
+				Annotations: map[string]string{e.cordonAnnotation(): "true"},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				LeaseDurationSeconds: int32Ptr(int32(e.config.LeaseDuration / time.Second)),
+			},
+		},
+		metav1.CreateOptions{},
+	)
+
+	return err
+}
+
+func (e *Elector) setCordoned(cordoned bool) {
+	e.cordoned.Store(cordoned)
+	e.metrics.setCordoned(cordoned)
+}
+
+func (e *Elector) cordonAnnotation() string {
+	return cordonAnnotationPrefix + e.config.MemberID
+}
+
+func strPtr(s string) *string { return &s }
+func int32Ptr(i int32) *int32 { return &i }
@@ -2,21 +2,41 @@ package election
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
 	"k8s.io/client-go/tools/leaderelection"
-	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog/v2"
 )
 
+const (
+	// successorAnnotation designates the member that should take over the lease after a transfer.
+	successorAnnotation = "prometheus-elector.io/successor"
+	// successorDeadlineAnnotation is the time after which any member is allowed to take over the lease again.
+	successorDeadlineAnnotation = "prometheus-elector.io/successor-deadline"
+	// appliedTermAnnotation is the term for which the holder of the lease applied the leader configuration.
+	appliedTermAnnotation = "prometheus-elector.io/applied-term"
+)
+
 var (
 	ErrAlreadyRunning = errors.New("elector is already running")
 	ErrNotRunning     = errors.New("elector is not running")
+	ErrNotLeader      = errors.New("elector is not leading")
+	ErrInvalidMember  = errors.New("invalid member")
+	ErrCordoned       = errors.New("member is cordoned")
+
+	errSteppedDown     = errors.New("member stepped down recently")
+	errTransferPending = errors.New("lease is being transferred to another member")
 )
 
 type LeaderChecker interface {
@@ -27,9 +47,49 @@ type LeaderGetter interface {
 	GetLeader() string
 }
 
+type CordonChecker interface {
+	IsCordoned() bool
+}
+
+type ZoneGetter interface {
+	// GetZone returns the zone of the member.
+	GetZone() string
+	// GetLeaderZone returns the zone of the leader, as advertised on the lease.
+	GetLeaderZone() string
+}
+
+// FitnessGetter is implemented by the status of an election reporting the fitness of the member.
+type FitnessGetter interface {
+	// GetFitness returns the fitness score of the member, false if it is unknown.
+	GetFitness() (float64, bool)
+}
+
+// TermGetter returns the term of the current leadership.
+// The term is a fencing token: it is incremented every time the lease is acquired, including by the same member.
+type TermGetter interface {
+	GetTerm() int64
+}
+
 type Status interface {
 	LeaderGetter
 	LeaderChecker
+	CordonChecker
+	ZoneGetter
+	TermGetter
+}
+
+// Controller allows to move the leadership deliberately.
+type Controller interface {
+	// StepDown releases the lease and prevents this member from taking it back
+	// until another member acquires it or the transfer timeout elapses.
+	StepDown(ctx context.Context) error
+	// Transfer releases the lease and designates the given member as the next leader.
+	// Other members are not allowed to acquire it until the transfer timeout elapses.
+	Transfer(ctx context.Context, to string) error
+	// Cordon makes the member leave the election and stay out of it until uncordoned.
+	Cordon(ctx context.Context) error
+	// Uncordon allows the member to participate to the election again.
+	Uncordon(ctx context.Context) error
 }
 
 type Config struct {
@@ -39,15 +99,88 @@ type Config struct {
 	LeaseDuration  time.Duration
 	RenewDeadline  time.Duration
 	RetryPeriod    time.Duration
+	// How long a leader that stepped down or transferred its leadership waits
+	// for another member to take over before competing again.
+	TransferTimeout time.Duration
+	// Zone of the member, see LookupZone.
+	Zone string
+	// If set, members outside of this zone delay their acquisition attempts by OutOfZoneAcquireDelay,
+	// and an out of zone leader hands over the lease to any member of this zone participating to the election.
+	PreferredZone         string
+	OutOfZoneAcquireDelay time.Duration
+	// How often the member heartbeat is sent, 0 disables it.
+	HeartbeatPeriod time.Duration
+	// How long after its last heartbeat a member is considered stale.
+	MemberStaleTimeout time.Duration
+	// ConfigHash returns the hash of the configuration applied by the member, reported in its heartbeat.
+	ConfigHash func() string
+	// SourceHash returns the hash of the source configuration the member rendered its configuration from, reported in its heartbeat.
+	SourceHash func() string
+	// Fitness returns the fitness score of the member, reported in its heartbeat. It returns false until a score is computed.
+	Fitness func() (float64, bool)
+	// ConfigGating prevents the member from acquiring the lease while its source configuration is older than the one
+	// of another member taking part to the election. It requires the heartbeat and SourceConfigMap.
+	ConfigGating bool
+	// SourceConfigMap is the name of the ConfigMap, in the lease namespace, publishing the source configuration
+	// under the SourceConfigMapKey key. Its resourceVersion orders the source configurations of the members.
+	SourceConfigMap    string
+	SourceConfigMapKey string
+	// History records the election events seen by the member, nil disables it.
+	History *History
+	// Coordinated delegates the choice of the leader to the coordinated leader election of Kubernetes:
+	// the member registers itself as a LeaseCandidate and only renews the lease once the coordinator assigned it.
+	Coordinated bool
+	// Candidate describes the member to the coordinator, only used by the coordinated election.
+	Candidate Candidate
 }
 
 type Elector struct {
+	config  Config
 	elector *leaderelection.LeaderElector
+	lock    *leaseLock
 
 	mu           sync.RWMutex
+	parentCtx    context.Context
 	runCtx       context.Context
 	cancelRunCtx func()
 	electorDone  chan struct{}
+	// stopping is set while a call waits for the elector to exit.
+	stopping bool
+
+	stepDownMu    sync.Mutex
+	stepDownUntil time.Time
+
+	// Set when the last call to Start wasn't followed by a call to Stop.
+	joinRequested bool
+	cordonLoaded  bool
+	cordoned      atomic.Bool
+	// cordonedByLease is set while the member is cordoned because of the lease annotation, rather than through Cordon.
+	cordonedByLease atomic.Bool
+	watchingCordon  atomic.Bool
+
+	leadingSince atomic.Pointer[time.Time]
+	joinedAt     atomic.Pointer[time.Time]
+
+	// stopReason is set when the member deliberately stops the elector, telling a release from a lost lease.
+	stopReason     atomic.Pointer[string]
+	observedHolder atomic.Pointer[string]
+	// leaderlessSince is when the member observed that the lease had no active holder anymore, nil if it has one.
+	leaderlessSince atomic.Pointer[time.Time]
+
+	handingOver          atomic.Bool
+	advertisingCandidacy atomic.Bool
+	// holding is set when the member acquired or resumed the lease, until it stops leading.
+	holding atomic.Bool
+	// resuming is set when the member resumed a lease it held before starting, until it starts leading.
+	resuming atomic.Bool
+
+	acquireGuard func(lease *coordinationv1.Lease, write func() error) error
+
+	heartbeat *heartbeat
+	// candidate is only set for the coordinated election.
+	candidate *candidate
+
+	metrics *electorMetrics
 }
 
 func New(cfg Config, k8sClient kubernetes.Interface, callbacks leaderelection.LeaderCallbacks, reg prometheus.Registerer) (*Elector, error) {
@@ -55,24 +188,80 @@ func New(cfg Config, k8sClient kubernetes.Interface, callbacks leaderelection.Le
 		return newLeaderMetrics(reg)
 	}))
 
+	return newElector(cfg, k8sClient, callbacks, newElectorMetrics(reg), nil)
+}
+
+// newElector builds an elector. If set, acquireGuard wraps every attempt of acquiring the lease.
+func newElector(
+	cfg Config,
+	k8sClient kubernetes.Interface,
+	callbacks leaderelection.LeaderCallbacks,
+	metrics *electorMetrics,
+	acquireGuard func(lease *coordinationv1.Lease, write func() error) error,
+) (*Elector, error) {
+	e := Elector{
+		config:       cfg,
+		metrics:      metrics,
+		acquireGuard: acquireGuard,
+	}
+
+	e.lock = &leaseLock{
+		leaseMeta: metav1.ObjectMeta{
+			Name:      cfg.LeaseName,
+			Namespace: cfg.LeaseNamespace,
+		},
+		client:   k8sClient.CoordinationV1(),
+		identity: cfg.MemberID,
+		acquire:  e.acquire,
+		renew:    e.renew,
+		observe:  e.observe,
+		renewed: func(duration time.Duration) {
+			metrics.observeRenewDuration(cfg.LeaseName, duration)
+		},
+	}
+
+	e.heartbeat = newHeartbeat(cfg, k8sClient, e.memberState)
+
+	if cfg.Coordinated {
+		e.candidate = newCandidate(cfg, k8sClient.CoordinationV1beta1().LeaseCandidates(cfg.LeaseNamespace))
+	}
+
 	le, err := leaderelection.NewLeaderElector(
 		leaderelection.LeaderElectionConfig{
-			Lock: &resourcelock.LeaseLock{
-				LeaseMeta: metav1.ObjectMeta{
-					Name:      cfg.LeaseName,
-					Namespace: cfg.LeaseNamespace,
-				},
-				Client: k8sClient.CoordinationV1(),
-				LockConfig: resourcelock.ResourceLockConfig{
-					Identity: cfg.MemberID,
-				},
-			},
+			Lock:            e.lock,
 			Name:            cfg.MemberID, // required to properly set election metrics.
 			ReleaseOnCancel: true,
+			Coordinated:     cfg.Coordinated,
 			LeaseDuration:   cfg.LeaseDuration,
 			RenewDeadline:   cfg.RenewDeadline,
 			RetryPeriod:     cfg.RetryPeriod,
-			Callbacks:       callbacks,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					now := time.Now()
+					e.leadingSince.Store(&now)
+					e.metrics.startedLeading(cfg.LeaseName)
+
+					if e.resuming.Swap(false) {
+						e.recordHistory(HistoryEventResumed, e.config.MemberID, "")
+					} else {
+						e.recordHistory(HistoryEventAcquired, e.config.MemberID, "")
+					}
+
+					callbacks.OnStartedLeading(ctx)
+				},
+				OnStoppedLeading: func() {
+					e.holding.Store(false)
+
+					// OnStoppedLeading is also called when the elector never led.
+					if leadingSince := e.leadingSince.Swap(nil); leadingSince != nil {
+						e.metrics.stoppedLeading(cfg.LeaseName, *leadingSince)
+						e.recordStoppedLeading()
+					}
+
+					callbacks.OnStoppedLeading()
+				},
+				OnNewLeader: callbacks.OnNewLeader,
+			},
 		},
 	)
 
@@ -80,10 +269,85 @@ func New(cfg Config, k8sClient kubernetes.Interface, callbacks leaderelection.Le
 		return nil, err
 	}
 
-	return &Elector{elector: le}, nil
+	e.elector = le
+
+	return &e, nil
+}
+
+func (e *Elector) Status() Status { return e }
+
+func (e *Elector) IsLeader() bool    { return e.elector.IsLeader() }
+func (e *Elector) GetLeader() string { return e.elector.GetLeader() }
+func (e *Elector) IsCordoned() bool  { return e.cordoned.Load() }
+
+func (e *Elector) GetTerm() int64 { return leaseTerm(e.lock.observedLease()) }
+
+func (e *Elector) GetFitness() (float64, bool) {
+	if e.config.Fitness == nil {
+		return 0, false
+	}
+
+	return e.config.Fitness()
 }
 
-func (e *Elector) Status() Status { return e.elector }
+// LeadingSince returns when the member started leading, zero if it is not leading.
+func (e *Elector) LeadingSince() time.Time {
+	since := e.leadingSince.Load()
+	if since == nil {
+		return time.Time{}
+	}
+
+	return *since
+}
+
+// RunHeartbeat maintains the member heartbeat until the given context is done.
+func (e *Elector) RunHeartbeat(ctx context.Context) error { return e.heartbeat.run(ctx) }
+
+// SetHealthy reports the health of the local Prometheus in the member heartbeat.
+func (e *Elector) SetHealthy(healthy bool) { e.heartbeat.setHealthy(healthy) }
+
+func (e *Elector) Members(ctx context.Context) ([]Member, error) {
+	return listMembers(ctx, e.leases(), e.config)
+}
+
+func (e *Elector) History() []HistoryEvent { return e.config.History.Events() }
+
+func (e *Elector) recordHistory(eventType, leader, reason string) {
+	e.config.History.Record(HistoryEvent{
+		Type:   eventType,
+		Lease:  e.config.LeaseName,
+		Leader: leader,
+		Term:   e.GetTerm(),
+		Reason: reason,
+	})
+}
+
+// recordStoppedLeading records the end of the leadership of the member, telling a release from a lost lease.
+func (e *Elector) recordStoppedLeading() {
+	if reason := e.stopReason.Load(); reason != nil {
+		e.recordHistory(HistoryEventReleased, e.config.MemberID, *reason)
+		return
+	}
+
+	e.recordHistory(HistoryEventLost, e.config.MemberID, "unable to renew the lease")
+}
+
+func (e *Elector) memberState() string {
+	e.mu.RLock()
+	running := e.runCtx != nil
+	e.mu.RUnlock()
+
+	switch {
+	case e.IsCordoned():
+		return MemberStateCordoned
+	case e.IsLeader():
+		return MemberStateLeader
+	case running:
+		return MemberStateFollower
+	default:
+		return MemberStateLeft
+	}
+}
 
 func (e *Elector) Start(ctx context.Context) error {
 	e.mu.RLock()
@@ -101,10 +365,414 @@ func (e *Elector) Start(ctx context.Context) error {
 		return ErrAlreadyRunning
 	}
 
+	e.joinRequested = true
+
+	if !e.cordonLoaded {
+		if err := e.loadCordon(ctx); err != nil {
+			klog.ErrorS(err, "Unable to load the cordon state from the lease")
+		}
+	}
+
+	if e.IsCordoned() {
+		// Uncordoning the member makes it join the election with this context.
+		e.parentCtx = ctx
+
+		if e.cordonedByLease.Load() {
+			go e.watchCordon(ctx)
+		}
+
+		return ErrCordoned
+	}
+
+	e.startLocked(ctx)
+
+	return nil
+}
+
+func (e *Elector) Stop(ctx context.Context) error {
+	e.mu.RLock()
+	currCtx := e.runCtx
+	e.mu.RUnlock()
+
+	if currCtx == nil {
+		e.mu.Lock()
+		e.joinRequested = false
+		e.mu.Unlock()
+
+		return ErrNotRunning
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.joinRequested = false
+
+	if e.runCtx == nil {
+		return ErrNotRunning
+	}
+
+	// Another call is already stopping the elector.
+	if e.stopping {
+		if err := e.waitElectorLocked(ctx, e.electorDone); err != nil {
+			return err
+		}
+
+		if e.runCtx == nil {
+			return ErrNotRunning
+		}
+	}
+
+	reason := "left the election"
+	if *e.heartbeat.health.Load() == MemberHealthUnhealthy {
+		reason = "Prometheus is unhealthy"
+	}
+
+	return e.leaveLocked(ctx, reason)
+}
+
+// StepDown releases the lease and prevents this member from taking it back until another member acquires it
+// or the transfer timeout elapses. The release doesn't depend on the given context, see stepDown.
+func (e *Elector) StepDown(context.Context) error {
+	return e.stepDown(nil, e.config.TransferTimeout, "stepped down")
+}
+
+// StepDownFor releases the lease for the given reason and prevents this member from taking it back
+// until another member acquires it or the given cooldown elapses.
+func (e *Elector) StepDownFor(_ context.Context, cooldown time.Duration, reason string) error {
+	return e.stepDown(nil, cooldown, reason)
+}
+
+// Transfer releases the lease and designates the given member as the next leader.
+// The member has to be live, healthy and taking part to the election according to its heartbeat,
+// otherwise every member would refuse to acquire the lease until the transfer times out.
+func (e *Elector) Transfer(ctx context.Context, to string) error {
+	if to == "" || to == e.config.MemberID {
+		return fmt.Errorf("%w: can't transfer the leadership to %q", ErrInvalidMember, to)
+	}
+
+	members, err := e.Members(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list the members: %w", err)
+	}
+
+	if !eligibleSuccessor(members, to) {
+		return fmt.Errorf("%w: %q is not a live and healthy follower", ErrInvalidMember, to)
+	}
+
+	return e.stepDown(e.successorAnnotations(to), e.config.TransferTimeout, "transferred the leadership to "+to)
+}
+
+// eligibleSuccessor tells if the given member is a live and healthy follower, which can take over the leadership.
+func eligibleSuccessor(members []Member, memberID string) bool {
+	for _, member := range members {
+		if member.ID == memberID {
+			return !member.Stale && member.State == MemberStateFollower && member.Health != MemberHealthUnhealthy
+		}
+	}
+
+	return false
+}
+
+func (e *Elector) successorAnnotations(to string) map[string]string {
+	return map[string]string{
+		successorAnnotation:         to,
+		successorDeadlineAnnotation: time.Now().Add(e.config.TransferTimeout).Format(time.RFC3339),
+	}
+}
+
+// stepDown restarts the elector while refusing to acquire the lease again for the given cooldown.
+// The given annotations are written on the lease when it gets released.
+// The elector is stopped with its own timeout: a step-down requested through the API must not be interrupted
+// by the end of the request, the member would stay out of the election.
+func (e *Elector) stepDown(annotations map[string]string, cooldown time.Duration, reason string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.runCtx == nil {
+		return ErrNotRunning
+	}
+
+	if !e.elector.IsLeader() {
+		return ErrNotLeader
+	}
+
+	e.stepDownMu.Lock()
+	e.stepDownUntil = time.Now().Add(cooldown)
+	e.stepDownMu.Unlock()
+
+	if len(annotations) > 0 {
+		e.lock.setPendingAnnotations(annotations)
+	}
+
+	klog.InfoS("Stepping down from the leadership", "reason", reason, "successor", annotations[successorAnnotation])
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.RenewDeadline)
+	defer cancel()
+
+	// If the elector takes longer to exit, the member joins again once it did.
+	if err := e.stopLocked(ctx, reason); err != nil {
+		return err
+	}
+
+	e.rejoinLocked()
+
+	return nil
+}
+
+// rejoinLocked starts the elector again after it stopped, unless the member left the election, or got cordoned, meanwhile.
+// It must be called with e.mu held.
+func (e *Elector) rejoinLocked() {
+	if e.runCtx != nil || !e.joinRequested || e.IsCordoned() || e.parentCtx == nil {
+		return
+	}
+
+	e.startLocked(e.parentCtx)
+}
+
+// acquire is called by the lease lock on every attempt of taking over the lease.
+func (e *Elector) acquire(lease *coordinationv1.Lease, write func() error) error {
+	if err := e.canAcquire(lease); err != nil {
+		return err
+	}
+
+	return e.hold(lease, write)
+}
+
+// renew is called by the lease lock on every renewal of the lease.
+// Renewing a lease that the member doesn't hold yet means that it still holds it from a previous run, for instance before
+// its container restarted: the leadership is resumed as is, keeping its term, instead of going through an acquisition.
+func (e *Elector) renew(lease *coordinationv1.Lease, write func() error) error {
+	if e.holding.Load() {
+		return write()
+	}
+
+	// The coordinator assigns the lease, the member only renews it.
+	if e.config.Coordinated {
+		klog.InfoS("Coordinator assigned the lease to the member", "lease", e.config.LeaseName, "term", leaseTerm(lease))
+
+		return e.hold(lease, write)
+	}
+
+	klog.InfoS("Member still holds the lease, resuming its leadership", "lease", e.config.LeaseName, "term", leaseTerm(lease))
+
+	if err := e.hold(lease, write); err != nil {
+		return err
+	}
+
+	e.resuming.Store(true)
+
+	return nil
+}
+
+// hold writes the lease through the acquire guard and records that the member holds it.
+func (e *Elector) hold(lease *coordinationv1.Lease, write func() error) error {
+	held := func() error {
+		if err := write(); err != nil {
+			return err
+		}
+
+		e.holding.Store(true)
+
+		return nil
+	}
+
+	if e.acquireGuard == nil {
+		return held()
+	}
+
+	return e.acquireGuard(lease, held)
+}
+
+func (e *Elector) canAcquire(lease *coordinationv1.Lease) error {
+	now := time.Now()
+
+	successor, hasSuccessor := lease.Annotations[successorAnnotation]
+	if hasSuccessor && successor != e.config.MemberID && beforeDeadline(lease, successorDeadlineAnnotation, now) {
+		return errTransferPending
+	}
+
+	e.stepDownMu.Lock()
+	stepDownUntil := e.stepDownUntil
+	e.stepDownMu.Unlock()
+
+	if now.Before(stepDownUntil) {
+		return errSteppedDown
+	}
+
+	if err := e.checkConfigOutdated(); err != nil {
+		return err
+	}
+
+	// The designated successor doesn't have to wait.
+	if successor != e.config.MemberID {
+		if err := e.delayOutOfZoneAcquisition(lease, now); err != nil {
+			return err
+		}
+	}
+
+	// We're about to take the lease, any past transfer is now done,
+	// and the leader configuration is yet to be applied for the new term.
+	annotations := map[string]string{appliedTermAnnotation: ""}
+
+	if hasSuccessor {
+		annotations[successorAnnotation] = ""
+		annotations[successorDeadlineAnnotation] = ""
+	}
+
+	if e.config.PreferredZone != "" {
+		annotations[holderZoneAnnotation] = e.config.Zone
+
+		if _, ok := lease.Annotations[zoneCandidateAnnotation]; ok {
+			annotations[zoneCandidateAnnotation] = ""
+			annotations[zoneCandidateDeadlineAnnotation] = ""
+		}
+	}
+
+	e.lock.setPendingAnnotations(annotations)
+
+	return nil
+}
+
+// observe is called by the lease lock every time the lease is read or written.
+func (e *Elector) observe(lease *coordinationv1.Lease) {
+	if holder := lease.Spec.HolderIdentity; holder != nil && *holder != "" && *holder != e.config.MemberID {
+		// Someone else took over, we're free to compete again.
+		e.stepDownMu.Lock()
+		e.stepDownUntil = time.Time{}
+		e.stepDownMu.Unlock()
+	}
+
+	if _, ok := lease.Annotations[e.cordonAnnotation()]; ok && !e.IsCordoned() {
+		klog.Info("Member has been cordoned through the lease, leaving the election")
+
+		e.setCordoned(true)
+		e.cordonedByLease.Store(true)
+
+		// This is called from the election loop, leave from another goroutine to avoid a deadlock.
+		go e.leave()
+	}
+
+	e.observeZone(lease)
+
+	e.metrics.setTerm(e.config.LeaseName, leaseTerm(lease))
+
+	e.observeLeader(lease)
+
+	var holder string
+	if lease.Spec.HolderIdentity != nil {
+		holder = *lease.Spec.HolderIdentity
+	}
+
+	if previous := e.observedHolder.Swap(&holder); holder != "" && (previous == nil || *previous != holder) {
+		e.config.History.Record(HistoryEvent{
+			Type:   HistoryEventNewLeader,
+			Lease:  e.config.LeaseName,
+			Leader: holder,
+			Term:   leaseTerm(lease),
+		})
+	}
+}
+
+// observeLeader exports the active holder of the lease, and how long the lease went without any.
+func (e *Elector) observeLeader(lease *coordinationv1.Lease) {
+	now := time.Now()
+	leader := activeHolder(lease, now)
+
+	e.metrics.setLeader(e.config.LeaseName, leader)
+
+	if leader == "" {
+		since := e.leaderlessSince.Load()
+		if since == nil {
+			since = &now
+			e.leaderlessSince.Store(since)
+		}
+
+		e.metrics.setLeaderless(e.config.LeaseName, now.Sub(*since))
+
+		return
+	}
+
+	if since := e.leaderlessSince.Swap(nil); since != nil {
+		e.metrics.setLeaderless(e.config.LeaseName, now.Sub(*since))
+	}
+}
+
+func (e *Elector) leases() coordinationv1client.LeaseInterface {
+	return e.lock.client.Leases(e.config.LeaseNamespace)
+}
+
+// patchAnnotations merge patches the annotations of the lease, a nil value removes the annotation.
+func (e *Elector) patchAnnotations(ctx context.Context, annotations map[string]*string) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = e.leases().Patch(ctx, e.config.LeaseName, types.MergePatchType, patch, metav1.PatchOptions{})
+
+	return err
+}
+
+// leaseTerm returns the term carried by the given lease, 0 if nil.
+func leaseTerm(lease *coordinationv1.Lease) int64 {
+	if lease == nil || lease.Spec.LeaseTransitions == nil {
+		return 0
+	}
+
+	return int64(*lease.Spec.LeaseTransitions)
+}
+
+// activeHolder returns the holder of the given lease, or an empty string if it has been released or has expired.
+func activeHolder(lease *coordinationv1.Lease, now time.Time) string {
+	spec := lease.Spec
+
+	if spec.HolderIdentity == nil || spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+		return ""
+	}
+
+	if now.After(spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second)) {
+		return ""
+	}
+
+	return *spec.HolderIdentity
+}
+
+// beforeDeadline tells if the RFC3339 deadline stored in the given annotation is not passed yet.
+func beforeDeadline(lease *coordinationv1.Lease, annotation string, now time.Time) bool {
+	deadline, err := time.Parse(time.RFC3339, lease.Annotations[annotation])
+
+	return err == nil && now.Before(deadline)
+}
+
+// startLocked must be called with e.mu held.
+func (e *Elector) startLocked(ctx context.Context) {
+	now := time.Now()
+	e.joinedAt.Store(&now)
+	e.stopReason.Store(nil)
+	e.leaderlessSince.Store(nil)
+
+	e.parentCtx = ctx
 	e.runCtx, e.cancelRunCtx = context.WithCancel(ctx)
 	e.electorDone = make(chan struct{})
 
-	go func(runCtx context.Context) {
+	// The coordinated election requires the member to be a candidate while it participates.
+	candidateDone := make(chan struct{})
+
+	if e.candidate == nil {
+		close(candidateDone)
+	} else {
+		go func(runCtx context.Context) {
+			defer close(candidateDone)
+
+			e.candidate.run(runCtx)
+		}(e.runCtx)
+	}
+
+	go func(runCtx context.Context, electorDone chan struct{}) {
 		for {
 			e.elector.Run(runCtx)
 
@@ -117,44 +785,76 @@ func (e *Elector) Start(ctx context.Context) error {
 			// In that case, we reeattempt to join the election by looping back and calling  elector.Run again.
 			select {
 			case <-runCtx.Done():
-				close(e.electorDone)
+				<-candidateDone
+				close(electorDone)
 				return
 			default:
 				klog.Info("elector exited while still being supposed to participate, re-joining the election...")
 			}
 		}
-	}(e.runCtx)
+	}(e.runCtx, e.electorDone)
+}
 
-	return nil
+// leaveLocked records that the member left the election for the given reason, then stops the elector.
+// It must be called with e.mu held.
+func (e *Elector) leaveLocked(ctx context.Context, reason string) error {
+	e.recordHistory(HistoryEventLeft, e.GetLeader(), reason)
+
+	return e.stopLocked(ctx, reason)
 }
 
-func (e *Elector) Stop(ctx context.Context) error {
-	e.mu.RLock()
-	currCtx := e.runCtx
-	e.mu.RUnlock()
+// stopLocked stops the elector, releasing the lease for the given reason if leading.
+// It must be called with e.mu held, which is released while waiting for the elector to exit:
+// the leadership callbacks run until then, and must not block the status of the member.
+func (e *Elector) stopLocked(ctx context.Context, reason string) error {
+	e.stopReason.Store(&reason)
+	e.cancelRunCtx()
+	e.stopping = true
 
-	if currCtx == nil {
-		return ErrNotRunning
+	electorDone := e.electorDone
+
+	if err := e.waitElectorLocked(ctx, electorDone); err != nil {
+		// The elector still exits, the member must then be able to join the election again.
+		go e.clearOnceStopped(electorDone)
+
+		return err
 	}
 
+	return nil
+}
+
+// clearOnceStopped clears the given elector once it exited, and rejoins the election if the member is still supposed to take part.
+func (e *Elector) clearOnceStopped(electorDone chan struct{}) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	if e.runCtx == nil {
-		return ErrNotRunning
-	}
+	_ = e.waitElectorLocked(context.Background(), electorDone)
 
-	e.cancelRunCtx()
+	e.rejoinLocked()
+}
+
+// waitElectorLocked releases e.mu until the given elector exited, then clears it.
+func (e *Elector) waitElectorLocked(ctx context.Context, electorDone chan struct{}) error {
+	e.mu.Unlock()
 
 	select {
 	case <-ctx.Done():
+		e.mu.Lock()
 		return ctx.Err()
-	case <-e.electorDone:
+	case <-electorDone:
+	}
+
+	e.mu.Lock()
+
+	// Another call already cleared the elector, and possibly started it again, while waiting.
+	if e.electorDone != electorDone {
+		return nil
 	}
 
 	e.runCtx = nil
 	e.cancelRunCtx = nil
 	e.electorDone = nil
+	e.stopping = false
 
 	return nil
 }
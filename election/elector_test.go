@@ -3,14 +3,19 @@ package election_test
 import (
 	"context"
 	"errors"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/jlevesy/prometheus-elector/election"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	kubetesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/leaderelection"
@@ -156,4 +161,416 @@ func TestElector_ContinuesParticipatingIfItLoosesTheLease(t *testing.T) {
 	<-startedLeading
 }
 
+// enforceLeaseResourceVersion makes the fake client reject lease updates based on a stale resource version,
+// like the API server does.
+func enforceLeaseResourceVersion(kubeClient *kubefake.Clientset) {
+	var (
+		tracker         = kubeClient.Tracker()
+		defaultReaction = kubetesting.ObjectReaction(tracker)
+		leasesGVR       = coordinationv1.SchemeGroupVersion.WithResource("leases")
+	)
+
+	bumpResourceVersion := func(lease *coordinationv1.Lease) (bool, runtime.Object, error) {
+		version, _ := strconv.Atoi(lease.ResourceVersion)
+		lease.ResourceVersion = strconv.Itoa(version + 1)
+
+		return true, lease, tracker.Update(leasesGVR, lease, lease.Namespace)
+	}
+
+	kubeClient.PrependReactor("update", "leases", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		lease := action.(kubetesting.UpdateAction).GetObject().(*coordinationv1.Lease).DeepCopy()
+
+		current, err := tracker.Get(leasesGVR, lease.Namespace, lease.Name)
+		if err != nil {
+			return true, nil, err
+		}
+
+		if current.(*coordinationv1.Lease).ResourceVersion != lease.ResourceVersion {
+			return true, nil, apierrors.NewConflict(leasesGVR.GroupResource(), lease.Name, errors.New("stale resource version"))
+		}
+
+		return bumpResourceVersion(lease)
+	})
+
+	kubeClient.PrependReactor("patch", "leases", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		_, obj, err := defaultReaction(action)
+		if err != nil {
+			return true, nil, err
+		}
+
+		return bumpResourceVersion(obj.(*coordinationv1.Lease).DeepCopy())
+	})
+}
+
 func strPtr(s string) *string { return &s }
+
+func TestElector_Term(t *testing.T) {
+	var (
+		ctx                                     = context.Background()
+		kubeClient                              = kubefake.NewClientset()
+		elector, startedLeading, stoppedLeading = newTestElector(t, kubeClient, "foo")
+	)
+
+	require.NoError(t, elector.Start(ctx))
+	<-startedLeading
+
+	firstTerm := elector.GetTerm()
+
+	// Leaving and joining back starts a new leadership, with a new term.
+	require.NoError(t, elector.Stop(ctx))
+	<-stoppedLeading
+
+	require.NoError(t, elector.Start(ctx))
+	<-startedLeading
+
+	assert.Equal(t, firstTerm+1, elector.GetTerm())
+}
+
+func TestElector_StepDown(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset()
+	)
+
+	foo, fooStarted, _ := newTestElector(t, kubeClient, "foo")
+	bar, barStarted, _ := newTestElector(t, kubeClient, "bar")
+
+	err := foo.StepDown(ctx)
+	assert.Equal(t, election.ErrNotRunning, err)
+
+	require.NoError(t, foo.Start(ctx))
+	<-fooStarted
+
+	require.NoError(t, bar.Start(ctx))
+
+	err = bar.StepDown(ctx)
+	assert.Equal(t, election.ErrNotLeader, err)
+
+	require.NoError(t, foo.StepDown(ctx))
+
+	<-barStarted
+	assert.True(t, bar.Status().IsLeader())
+	assert.False(t, foo.Status().IsLeader())
+	assert.Eventually(
+		t,
+		func() bool { return foo.Status().GetLeader() == "bar" },
+		5*time.Second,
+		100*time.Millisecond,
+	)
+}
+
+func TestElector_StepDownFor(t *testing.T) {
+	var (
+		ctx                                = context.Background()
+		kubeClient                         = kubefake.NewClientset()
+		foo, fooStarted, fooStoppedLeading = newTestElector(t, kubeClient, "foo")
+		cooldown                           = 2 * time.Second
+	)
+
+	require.NoError(t, foo.Start(ctx))
+	<-fooStarted
+
+	steppedDownAt := time.Now()
+
+	require.NoError(t, foo.StepDownFor(ctx, cooldown, "unable to apply the leader configuration"))
+	<-fooStoppedLeading
+
+	// Without any other member, the lease is only taken back once the cooldown elapsed.
+	<-fooStarted
+	assert.GreaterOrEqual(t, time.Since(steppedDownAt), cooldown)
+}
+
+func TestElector_StepDownCancelled(t *testing.T) {
+	var (
+		kubeClient                         = kubefake.NewClientset()
+		foo, fooStarted, fooStoppedLeading = newTestElector(t, kubeClient, "foo")
+		cooldown                           = 100 * time.Millisecond
+		releaseDelay                       atomic.Int64
+	)
+
+	// Releasing the lease can take longer than the renew deadline.
+	kubeClient.PrependReactor("update", "leases", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		lease := action.(kubetesting.UpdateAction).GetObject().(*coordinationv1.Lease)
+		if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == "" {
+			time.Sleep(time.Duration(releaseDelay.Load()))
+		}
+
+		return false, nil, nil
+	})
+
+	require.NoError(t, foo.Start(context.Background()))
+	<-fooStarted
+
+	// The request ends before the member steps down, the step-down still goes on.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.NoError(t, foo.StepDownFor(ctx, cooldown, "stepped down"))
+	<-fooStoppedLeading
+
+	// The member joins the election again.
+	<-fooStarted
+
+	// The elector doesn't exit within the renew deadline, the member joins the election again once it did.
+	releaseDelay.Store(int64(time.Second))
+
+	err := foo.StepDownFor(context.Background(), cooldown, "stepped down")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	<-fooStoppedLeading
+
+	select {
+	case <-fooStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("member didn't join the election again")
+	}
+
+	assert.Equal(t, election.ErrAlreadyRunning, foo.Start(context.Background()))
+}
+
+func TestElector_Transfer(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		kubeClient  = kubefake.NewClientset()
+	)
+
+	defer cancel()
+
+	newMemberConfig := func(memberID string) election.Config {
+		cfg := newTestConfig(memberID)
+		cfg.HeartbeatPeriod = 100 * time.Millisecond
+		cfg.MemberStaleTimeout = 10 * time.Second
+
+		return cfg
+	}
+
+	foo, fooStarted, _ := newTestElectorWithConfig(t, kubeClient, newMemberConfig("foo"))
+	bar, barStarted, _ := newTestElectorWithConfig(t, kubeClient, newMemberConfig("bar"))
+	baz, bazStarted, _ := newTestElectorWithConfig(t, kubeClient, newMemberConfig("baz"))
+	biz, _, _ := newTestElectorWithConfig(t, kubeClient, newMemberConfig("biz"))
+
+	require.NoError(t, foo.Start(ctx))
+	<-fooStarted
+
+	require.NoError(t, bar.Start(ctx))
+	require.NoError(t, baz.Start(ctx))
+
+	// biz publishes its heartbeat, but doesn't take part to the election.
+	for _, member := range []*election.Elector{foo, bar, baz, biz} {
+		go func() { _ = member.RunHeartbeat(ctx) }()
+	}
+
+	assert.Eventually(t, func() bool {
+		members, err := foo.Members(ctx)
+		require.NoError(t, err)
+
+		return len(members) == 4
+	}, 5*time.Second, 100*time.Millisecond)
+
+	for _, to := range []string{"foo", "unknown", "biz"} {
+		err := foo.Transfer(ctx, to)
+		assert.ErrorIs(t, err, election.ErrInvalidMember)
+		assert.True(t, foo.Status().IsLeader())
+	}
+
+	require.NoError(t, foo.Transfer(ctx, "baz"))
+
+	<-bazStarted
+	assert.True(t, baz.Status().IsLeader())
+	assert.Eventually(
+		t,
+		func() bool { return bar.Status().GetLeader() == "baz" },
+		5*time.Second,
+		100*time.Millisecond,
+	)
+
+	select {
+	case <-barStarted:
+		t.Fatal("bar should not have acquired the lease")
+	default:
+	}
+
+	// Transfer annotations are cleared by the new leader.
+	lease, err := kubeClient.CoordinationV1().Leases("test").Get(ctx, "test", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, lease.Annotations, "prometheus-elector.io/successor")
+}
+
+func TestElector_Cordon(t *testing.T) {
+	var (
+		ctx          = context.Background()
+		kubeClient   = kubefake.NewClientset()
+		leasesClient = kubeClient.CoordinationV1().Leases("test")
+	)
+
+	enforceLeaseResourceVersion(kubeClient)
+
+	foo, fooStarted, _ := newTestElector(t, kubeClient, "foo")
+	bar, barStarted, _ := newTestElector(t, kubeClient, "bar")
+
+	require.NoError(t, foo.Start(ctx))
+	<-fooStarted
+
+	require.NoError(t, bar.Start(ctx))
+
+	// Cordoning the leader makes it release the lease.
+	require.NoError(t, foo.Cordon(ctx))
+	assert.True(t, foo.Status().IsCordoned())
+
+	<-barStarted
+	assert.True(t, bar.Status().IsLeader())
+
+	err := foo.Start(ctx)
+	assert.Equal(t, election.ErrCordoned, err)
+
+	lease, err := leasesClient.Get(ctx, "test", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "true", lease.Annotations["cordon.prometheus-elector.io/foo"])
+
+	// The cordon survives a restart.
+	restartedFoo, _, _ := newTestElector(t, kubeClient, "foo")
+	err = restartedFoo.Start(ctx)
+	assert.Equal(t, election.ErrCordoned, err)
+	assert.True(t, restartedFoo.Status().IsCordoned())
+	assert.Equal(t, election.ErrNotRunning, restartedFoo.Stop(ctx))
+
+	// Uncordoning makes the member join the election again.
+	require.NoError(t, foo.Uncordon(ctx))
+	assert.False(t, foo.Status().IsCordoned())
+	assert.Equal(t, election.ErrAlreadyRunning, foo.Start(ctx))
+
+	lease, err = leasesClient.Get(ctx, "test", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, lease.Annotations, "cordon.prometheus-elector.io/foo")
+
+	// Cordon the leader by annotating the lease.
+	_, err = leasesClient.Patch(
+		ctx,
+		"test",
+		types.MergePatchType,
+		[]byte(`{"metadata":{"annotations":{"cordon.prometheus-elector.io/bar":"true"}}}`),
+		metav1.PatchOptions{},
+	)
+	require.NoError(t, err)
+
+	<-fooStarted
+	assert.True(t, foo.Status().IsLeader())
+	assert.True(t, bar.Status().IsCordoned())
+
+	// Removing the annotation uncordons the member, which joins the election again.
+	_, err = leasesClient.Patch(
+		ctx,
+		"test",
+		types.MergePatchType,
+		[]byte(`{"metadata":{"annotations":{"cordon.prometheus-elector.io/bar":null}}}`),
+		metav1.PatchOptions{},
+	)
+	require.NoError(t, err)
+
+	assert.Eventually(
+		t,
+		func() bool { return !bar.Status().IsCordoned() },
+		5*time.Second,
+		100*time.Millisecond,
+	)
+	assert.Equal(t, election.ErrAlreadyRunning, bar.Start(ctx))
+	assert.False(t, restartedFoo.Status().IsCordoned())
+}
+
+func TestElector_CordonReleaseFailure(t *testing.T) {
+	var (
+		ctx          = context.Background()
+		kubeClient   = kubefake.NewClientset()
+		leasesClient = kubeClient.CoordinationV1().Leases("test")
+		failPatch    atomic.Bool
+	)
+
+	kubeClient.PrependReactor("patch", "leases", func(kubetesting.Action) (bool, runtime.Object, error) {
+		if failPatch.Load() {
+			return true, nil, errors.New("failed")
+		}
+
+		return false, nil, nil
+	})
+
+	// The lease can't be released.
+	kubeClient.PrependReactor("update", "leases", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		lease := action.(kubetesting.UpdateAction).GetObject().(*coordinationv1.Lease)
+		if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == "" {
+			return true, nil, errors.New("failed")
+		}
+
+		return false, nil, nil
+	})
+
+	foo, fooStarted, _ := newTestElector(t, kubeClient, "foo")
+
+	require.NoError(t, foo.Start(ctx))
+	<-fooStarted
+
+	// The cordon is still written on the lease.
+	require.NoError(t, foo.Cordon(ctx))
+
+	lease, err := leasesClient.Get(ctx, "test", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "true", lease.Annotations["cordon.prometheus-elector.io/foo"])
+
+	// Failing to write it is reported.
+	require.NoError(t, foo.Uncordon(ctx))
+	<-fooStarted
+
+	failPatch.Store(true)
+
+	assert.Error(t, foo.Cordon(ctx))
+}
+
+func newTestElector(t *testing.T, kubeClient *kubefake.Clientset, memberID string) (*election.Elector, chan struct{}, chan struct{}) {
+	t.Helper()
+
+	return newTestElectorWithConfig(t, kubeClient, newTestConfig(memberID))
+}
+
+func newTestConfig(memberID string) election.Config {
+	return election.Config{
+		LeaseName:       "test",
+		LeaseNamespace:  "test",
+		MemberID:        memberID,
+		LeaseDuration:   time.Second,
+		RenewDeadline:   500 * time.Millisecond,
+		RetryPeriod:     200 * time.Millisecond,
+		TransferTimeout: 10 * time.Second,
+	}
+}
+
+func newTestElectorWithConfig(t *testing.T, kubeClient *kubefake.Clientset, cfg election.Config) (*election.Elector, chan struct{}, chan struct{}) {
+	t.Helper()
+
+	var (
+		startedLeading = make(chan struct{}, 1)
+		stoppedLeading = make(chan struct{}, 1)
+	)
+
+	elector, err := election.New(
+		cfg,
+		kubeClient,
+		leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				startedLeading <- struct{}{}
+			},
+			OnStoppedLeading: func() {
+				select {
+				case stoppedLeading <- struct{}{}:
+				default:
+				}
+			},
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = elector.Stop(context.Background())
+	})
+
+	return elector, startedLeading, stoppedLeading
+}
@@ -0,0 +1,192 @@
+package election
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	coordinationv1listers "k8s.io/client-go/listers/coordination/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// configGenerationAnnotation is set on the source ConfigMap to order its successive contents,
+// it is bumped on every change, for instance to the release revision.
+const configGenerationAnnotation = "prometheus-elector.io/config-generation"
+
+var errConfigOutdated = errors.New("member source configuration is older than the one of the majority of the members")
+
+// configGating orders the source configurations of the members by the generation annotation of the ConfigMap they have been
+// published with, so that a member lagging behind a ConfigMap rollout doesn't acquire the lease.
+// The member heartbeats and the ConfigMap are read from a cache, acquisition attempts don't hit the API server.
+type configGating struct {
+	config Config
+
+	memberInformers    informers.SharedInformerFactory
+	memberLeases       coordinationv1listers.LeaseNamespaceLister
+	configMapInformers informers.SharedInformerFactory
+	configMaps         corev1listers.ConfigMapNamespaceLister
+
+	mu sync.Mutex
+	// generations maps the source hashes of the member to the generation they have been seen with.
+	generations map[string]int64
+}
+
+func newConfigGating(cfg Config, k8sClient kubernetes.Interface) *configGating {
+	memberInformers := informers.NewSharedInformerFactoryWithOptions(
+		k8sClient,
+		0,
+		informers.WithNamespace(cfg.LeaseNamespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = labels.SelectorFromSet(labels.Set{electionLabel: cfg.LeaseName}).String()
+		}),
+	)
+
+	configMapInformers := informers.NewSharedInformerFactoryWithOptions(
+		k8sClient,
+		0,
+		informers.WithNamespace(cfg.LeaseNamespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", cfg.SourceConfigMap).String()
+		}),
+	)
+
+	return &configGating{
+		config:             cfg,
+		memberInformers:    memberInformers,
+		memberLeases:       memberInformers.Coordination().V1().Leases().Lister().Leases(cfg.LeaseNamespace),
+		configMapInformers: configMapInformers,
+		configMaps:         configMapInformers.Core().V1().ConfigMaps().Lister().ConfigMaps(cfg.LeaseNamespace),
+		generations:        make(map[string]int64),
+	}
+}
+
+// run fills the caches until the given context is done. Until they are synced, the acquisition isn't gated.
+func (g *configGating) run(ctx context.Context) {
+	g.memberInformers.Start(ctx.Done())
+	g.configMapInformers.Start(ctx.Done())
+
+	<-ctx.Done()
+
+	g.memberInformers.Shutdown()
+	g.configMapInformers.Shutdown()
+}
+
+// generation returns the generation of the source configuration of the member, 0 if unknown.
+// The source configuration gets the generation of the ConfigMap as soon as its content matches it,
+// and keeps it once the ConfigMap moved on.
+func (g *configGating) generation() int64 {
+	if g.config.SourceHash == nil {
+		return 0
+	}
+
+	own := g.config.SourceHash()
+	if own == "" {
+		return 0
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	configMap, err := g.configMaps.Get(g.config.SourceConfigMap)
+	if err != nil {
+		return g.generations[own]
+	}
+
+	data, ok := configMap.Data[g.config.SourceConfigMapKey]
+	if !ok {
+		return g.generations[own]
+	}
+
+	hash := sha256.Sum256([]byte(data))
+	if hex.EncodeToString(hash[:]) != own {
+		return g.generations[own]
+	}
+
+	generation, err := strconv.ParseInt(configMap.Annotations[configGenerationAnnotation], 10, 64)
+	if err != nil {
+		return g.generations[own]
+	}
+
+	g.generations[own] = generation
+
+	return generation
+}
+
+// check refuses the acquisition while the majority of the members taking part to the election, counting this one,
+// runs a newer source configuration. The member becomes eligible again as soon as it catches up.
+func (g *configGating) check() error {
+	leases, err := g.memberLeases.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Unable to list the cached members, not gating the acquisition on the source configuration")
+		return nil
+	}
+
+	var (
+		now     = time.Now()
+		members = make([]Member, 0, len(leases))
+	)
+
+	for _, lease := range leases {
+		if member, ok := memberFromLease(lease, now, g.config); ok {
+			members = append(members, member)
+		}
+	}
+
+	if outdated(members, g.config.MemberID, g.generation()) {
+		return errConfigOutdated
+	}
+
+	return nil
+}
+
+// outdated tells if a strict majority of the members taking part to the election, counting this one,
+// runs a source configuration newer than the given generation.
+func outdated(members []Member, memberID string, generation int64) bool {
+	var participants, newer int
+
+	// This member takes part to the election, whatever its heartbeat says.
+	participants++
+
+	for _, member := range members {
+		if member.ID == memberID || !participating(member) {
+			continue
+		}
+
+		participants++
+
+		if member.SourceGeneration > generation {
+			newer++
+		}
+	}
+
+	return newer > participants/2
+}
+
+// participating tells if the member takes part to the election: its heartbeat is fresh, it didn't leave
+// the election nor has been cordoned, and its Prometheus isn't unhealthy.
+func participating(member Member) bool {
+	if member.Stale || member.Health == MemberHealthUnhealthy {
+		return false
+	}
+
+	return member.State == MemberStateLeader || member.State == MemberStateFollower
+}
+
+// checkConfigOutdated refuses the acquisition of the lease while the source configuration of the member is outdated.
+func (e *Elector) checkConfigOutdated() error {
+	if e.heartbeat.gating == nil {
+		return nil
+	}
+
+	return e.heartbeat.gating.check()
+}
@@ -0,0 +1,169 @@
+package election_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestElector_ConfigGating(t *testing.T) {
+	for _, testCase := range []struct {
+		desc           string
+		sourceConfig   string
+		members        []*coordinationv1.Lease
+		wantWaiting    bool
+		wantGeneration string
+	}{
+		{
+			desc:         "member behind the majority waits until it catches up",
+			sourceConfig: "old",
+			members: []*coordinationv1.Lease{
+				memberHeartbeat("foo", "follower", "healthy", 42),
+				memberHeartbeat("bar", "follower", "healthy", 42),
+			},
+			wantWaiting:    true,
+			wantGeneration: "42",
+		},
+		{
+			desc:         "member behind a minority doesn't wait",
+			sourceConfig: "old",
+			members: []*coordinationv1.Lease{
+				memberHeartbeat("foo", "follower", "healthy", 42),
+				memberHeartbeat("bar", "follower", "healthy", 0),
+			},
+		},
+		{
+			desc:         "member running the newest configuration doesn't wait for the majority",
+			sourceConfig: "new",
+			members: []*coordinationv1.Lease{
+				memberHeartbeat("foo", "follower", "healthy", 30),
+				memberHeartbeat("bar", "follower", "healthy", 30),
+			},
+			wantGeneration: "42",
+		},
+		{
+			desc:         "members not taking part to the election are ignored",
+			sourceConfig: "new",
+			members: []*coordinationv1.Lease{
+				memberHeartbeat("foo", "left", "healthy", 50),
+				memberHeartbeat("bar", "cordoned", "healthy", 50),
+				memberHeartbeat("buz", "follower", "unhealthy", 50),
+				staleHeartbeat(memberHeartbeat("boz", "follower", "healthy", 50)),
+			},
+			wantGeneration: "42",
+		},
+	} {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var (
+				ctx, cancel = context.WithCancel(context.Background())
+				kubeClient  = kubefake.NewClientset(
+					&corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "prometheus-elector-config",
+							Namespace:   "test",
+							Annotations: map[string]string{"prometheus-elector.io/config-generation": "42"},
+						},
+						Data: map[string]string{"config.yaml": "new"},
+					},
+				)
+				sourceHash atomic.Pointer[string]
+			)
+
+			defer cancel()
+
+			for _, member := range testCase.members {
+				_, err := kubeClient.CoordinationV1().Leases("test").Create(ctx, member, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			ownHash := hashOf(testCase.sourceConfig)
+			sourceHash.Store(&ownHash)
+
+			cfg := newTestConfig("biz")
+			cfg.HeartbeatPeriod = 100 * time.Millisecond
+			cfg.MemberStaleTimeout = time.Minute
+			cfg.ConfigGating = true
+			cfg.SourceConfigMap = "prometheus-elector-config"
+			cfg.SourceConfigMapKey = "config.yaml"
+			cfg.SourceHash = func() string { return *sourceHash.Load() }
+
+			biz, bizStarted, _ := newTestElectorWithConfig(t, kubeClient, cfg)
+
+			heartbeatDone := make(chan error)
+			go func() { heartbeatDone <- biz.RunHeartbeat(ctx) }()
+
+			// Let the heartbeat fill its cache.
+			time.Sleep(2 * cfg.HeartbeatPeriod)
+
+			require.NoError(t, biz.Start(ctx))
+
+			if testCase.wantWaiting {
+				// The member running an outdated configuration doesn't acquire the free lease.
+				time.Sleep(2 * cfg.LeaseDuration)
+				assert.False(t, biz.Status().IsLeader())
+
+				// Once it caught up, it is eligible again.
+				newHash := hashOf("new")
+				sourceHash.Store(&newHash)
+			}
+
+			select {
+			case <-bizStarted:
+			case <-time.After(5 * time.Second):
+				t.Fatal("member didn't acquire the lease")
+			}
+
+			assert.True(t, biz.Status().IsLeader())
+
+			// The member publishes the generation of its configuration, once known.
+			require.Eventually(t, func() bool {
+				heartbeat, err := kubeClient.CoordinationV1().Leases("test").Get(ctx, "test-member-biz", metav1.GetOptions{})
+				return err == nil && heartbeat.Annotations["prometheus-elector.io/member-source-generation"] == testCase.wantGeneration
+			}, 5*time.Second, 10*time.Millisecond)
+
+			require.NoError(t, biz.Stop(ctx))
+			cancel()
+			require.NoError(t, <-heartbeatDone)
+		})
+	}
+}
+
+func memberHeartbeat(memberID, state, health string, generation int64) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-member-" + memberID,
+			Namespace: "test",
+			Labels:    map[string]string{"prometheus-elector.io/election": "test"},
+			Annotations: map[string]string{
+				"prometheus-elector.io/member-state":             state,
+				"prometheus-elector.io/member-health":            health,
+				"prometheus-elector.io/member-source-generation": strconv.FormatInt(generation, 10),
+			},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity: strPtr(memberID),
+			RenewTime:      &metav1.MicroTime{Time: time.Now()},
+		},
+	}
+}
+
+func staleHeartbeat(lease *coordinationv1.Lease) *coordinationv1.Lease {
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now().Add(-time.Hour)}
+	return lease
+}
+
+func hashOf(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,164 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"k8s.io/klog/v2"
+)
+
+type GuardConfig struct {
+	// Minimum duration a member keeps the leadership before leaving the election.
+	MinLeadershipDuration time.Duration
+	// Delay to wait after leaving the election before joining it again.
+	// It doubles every time the member leaves again within MaxRejoinCooldown.
+	RejoinCooldown    time.Duration
+	MaxRejoinCooldown time.Duration
+}
+
+type guardedElector interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+	LeadingSince() time.Time
+}
+
+// Guard protects the election against a flapping member.
+// It defers leaving the election until the member has been leading long enough,
+// and delays joining the election again after leaving it.
+type Guard struct {
+	config  GuardConfig
+	elector guardedElector
+
+	mu      sync.Mutex
+	pending *time.Timer
+	// unwatch stops watching the context of the pending transition.
+	unwatch   func() bool
+	lastLeave time.Time
+	cooldown  time.Duration
+
+	suppressedTransitions *prometheus.CounterVec
+}
+
+func NewGuard(cfg GuardConfig, elector guardedElector, reg prometheus.Registerer) *Guard {
+	return &Guard{
+		config:   cfg,
+		elector:  elector,
+		cooldown: cfg.RejoinCooldown,
+		suppressedTransitions: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "prometheus_elector",
+				Name:      "election_suppressed_transitions_total",
+				Help:      "The total amount of times joining or leaving the election has been deferred to prevent flapping",
+			},
+			[]string{"transition"},
+		),
+	}
+}
+
+// Join joins the election, or schedules joining it once the rejoin cooldown elapsed.
+func (g *Guard) Join(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.cancelPending()
+
+	rejoinAt := g.lastLeave.Add(g.cooldown)
+	if delay := time.Until(rejoinAt); !g.lastLeave.IsZero() && delay > 0 {
+		klog.InfoS("Left the election recently, delaying join", "delay", delay)
+		g.suppressedTransitions.WithLabelValues("join").Inc()
+		g.schedule(ctx, delay, func() error { return g.elector.Start(ctx) })
+
+		return nil
+	}
+
+	return g.elector.Start(ctx)
+}
+
+// Leave leaves the election, or schedules leaving it once the member has been leading long enough.
+func (g *Guard) Leave(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.cancelPending()
+
+	if leadingSince := g.elector.LeadingSince(); !leadingSince.IsZero() {
+		if delay := time.Until(leadingSince.Add(g.config.MinLeadershipDuration)); delay > 0 {
+			klog.InfoS("Leading since too little time, delaying leave", "delay", delay)
+			g.suppressedTransitions.WithLabelValues("leave").Inc()
+			g.schedule(ctx, delay, func() error { return g.leave(ctx) })
+
+			return nil
+		}
+	}
+
+	return g.leave(ctx)
+}
+
+// leave must be called with g.mu held.
+// The rejoin cooldown only grows when the member actually left the election.
+func (g *Guard) leave(ctx context.Context) error {
+	if err := g.elector.Stop(ctx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	if !g.lastLeave.IsZero() && now.Sub(g.lastLeave) < g.config.MaxRejoinCooldown {
+		g.cooldown = min(2*g.cooldown, g.config.MaxRejoinCooldown)
+	} else {
+		g.cooldown = g.config.RejoinCooldown
+	}
+
+	g.lastLeave = now
+
+	return nil
+}
+
+// schedule must be called with g.mu held.
+// The action is dropped if the given context is done before the delay elapses, for instance when shutting down.
+func (g *Guard) schedule(ctx context.Context, delay time.Duration, action func() error) {
+	var timer *time.Timer
+
+	timer = time.AfterFunc(delay, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		// Superseded by another transition, or dropped.
+		if g.pending != timer || ctx.Err() != nil {
+			return
+		}
+
+		g.cancelPending()
+
+		err := action()
+		if err != nil && !errors.Is(err, ErrAlreadyRunning) && !errors.Is(err, ErrNotRunning) && !errors.Is(err, ErrCordoned) {
+			klog.ErrorS(err, "Unable to perform a deferred election transition")
+		}
+	})
+
+	g.pending = timer
+	g.unwatch = context.AfterFunc(ctx, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if g.pending == timer {
+			g.cancelPending()
+		}
+	})
+}
+
+// cancelPending must be called with g.mu held.
+func (g *Guard) cancelPending() {
+	if g.pending == nil {
+		return
+	}
+
+	g.pending.Stop()
+	g.unwatch()
+	g.pending = nil
+	g.unwatch = nil
+}
@@ -0,0 +1,133 @@
+package election_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/jlevesy/prometheus-elector/election"
+)
+
+func TestGuard(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset()
+		reg        = prometheus.NewRegistry()
+	)
+
+	elector, startedLeading, stoppedLeading := newTestElector(t, kubeClient, "foo")
+
+	guard := election.NewGuard(
+		election.GuardConfig{
+			MinLeadershipDuration: 500 * time.Millisecond,
+			RejoinCooldown:        500 * time.Millisecond,
+			MaxRejoinCooldown:     10 * time.Second,
+		},
+		elector,
+		reg,
+	)
+
+	require.NoError(t, guard.Join(ctx))
+	<-startedLeading
+
+	// Leaving right after acquiring the lease is deferred.
+	require.NoError(t, guard.Leave(ctx))
+	assert.True(t, elector.Status().IsLeader())
+
+	<-stoppedLeading
+	assert.False(t, elector.Status().IsLeader())
+	assert.Equal(t, election.ErrNotRunning, elector.Stop(ctx))
+
+	// Joining right after leaving is deferred too.
+	joinedAt := time.Now()
+	require.NoError(t, guard.Join(ctx))
+
+	<-startedLeading
+	assert.GreaterOrEqual(t, time.Since(joinedAt), 400*time.Millisecond)
+
+	// A deferred leave is cancelled if the member joins back in the meantime.
+	require.NoError(t, guard.Leave(ctx))
+	assert.Equal(t, election.ErrAlreadyRunning, guard.Join(ctx))
+
+	time.Sleep(time.Second)
+	assert.True(t, elector.Status().IsLeader())
+
+	// Leaving again shortly after doubles the cooldown.
+	require.NoError(t, guard.Leave(ctx))
+	<-stoppedLeading
+
+	joinedAt = time.Now()
+	require.NoError(t, guard.Join(ctx))
+
+	<-startedLeading
+	assert.GreaterOrEqual(t, time.Since(joinedAt), 900*time.Millisecond)
+
+	const wantMetrics = `
+# HELP prometheus_elector_election_suppressed_transitions_total The total amount of times joining or leaving the election has been deferred to prevent flapping
+# TYPE prometheus_elector_election_suppressed_transitions_total counter
+prometheus_elector_election_suppressed_transitions_total{transition="join"} 2
+prometheus_elector_election_suppressed_transitions_total{transition="leave"} 2
+`
+
+	assert.NoError(t, testutil.GatherAndCompare(
+		reg,
+		bytes.NewBuffer([]byte(wantMetrics)),
+		"prometheus_elector_election_suppressed_transitions_total",
+	))
+}
+
+func TestGuard_Shutdown(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		kubeClient  = kubefake.NewClientset()
+		reg         = prometheus.NewRegistry()
+	)
+
+	defer cancel()
+
+	elector, startedLeading, stoppedLeading := newTestElector(t, kubeClient, "foo")
+
+	guard := election.NewGuard(
+		election.GuardConfig{
+			RejoinCooldown:    500 * time.Millisecond,
+			MaxRejoinCooldown: 10 * time.Second,
+		},
+		elector,
+		reg,
+	)
+
+	// Leaving while not running doesn't start the cooldown.
+	assert.Equal(t, election.ErrNotRunning, guard.Leave(ctx))
+
+	require.NoError(t, guard.Join(ctx))
+	<-startedLeading
+
+	require.NoError(t, guard.Leave(ctx))
+	<-stoppedLeading
+
+	// A deferred join is dropped once the context is done.
+	require.NoError(t, guard.Join(ctx))
+	cancel()
+
+	time.Sleep(time.Second)
+	assert.Equal(t, election.ErrNotRunning, elector.Stop(context.Background()))
+
+	const wantMetrics = `
+# HELP prometheus_elector_election_suppressed_transitions_total The total amount of times joining or leaving the election has been deferred to prevent flapping
+# TYPE prometheus_elector_election_suppressed_transitions_total counter
+prometheus_elector_election_suppressed_transitions_total{transition="join"} 1
+`
+
+	assert.NoError(t, testutil.GatherAndCompare(
+		reg,
+		bytes.NewBuffer([]byte(wantMetrics)),
+		"prometheus_elector_election_suppressed_transitions_total",
+	))
+}
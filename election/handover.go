@@ -0,0 +1,62 @@
+package election
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// MarkApplied reports on the lease that the member applied the leader configuration for the given term.
+// The report is written with the next renewal of the lease, it is ignored if the member isn't leading for this term anymore.
+func (e *Elector) MarkApplied(term int64) {
+	if !e.IsLeader() || e.GetTerm() != term {
+		return
+	}
+
+	e.lock.setPendingAnnotations(map[string]string{appliedTermAnnotation: strconv.FormatInt(term, 10)})
+}
+
+// WaitForSuccessor is meant to be called after the member released the lease.
+// It waits until another member acquired the lease and reported that it applied the leader configuration, or until the given context is done.
+func (e *Elector) WaitForSuccessor(ctx context.Context) error {
+	var (
+		startTime    = time.Now()
+		releasedTerm = e.GetTerm()
+		ticker       = time.NewTicker(e.config.RetryPeriod)
+	)
+
+	defer ticker.Stop()
+
+	klog.InfoS("Waiting for the next leader to apply its configuration", "term", releasedTerm)
+
+	for {
+		lease, err := e.leases().Get(ctx, e.config.LeaseName, metav1.GetOptions{})
+		if err != nil && ctx.Err() == nil {
+			klog.ErrorS(err, "Unable to get the lease while waiting for the next leader")
+		}
+
+		if err == nil {
+			term := leaseTerm(lease)
+			holder := activeHolder(lease, time.Now())
+
+			if term > releasedTerm && holder != "" && holder != e.config.MemberID && lease.Annotations[appliedTermAnnotation] == strconv.FormatInt(term, 10) {
+				e.metrics.observeHandoverWait(e.config.LeaseName, time.Since(startTime), "completed")
+
+				klog.InfoS("Next leader applied its configuration", "leader", holder, "term", term, "waited", time.Since(startTime))
+
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			e.metrics.observeHandoverWait(e.config.LeaseName, time.Since(startTime), "timeout")
+
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
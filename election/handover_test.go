@@ -0,0 +1,73 @@
+package election_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestElector_WaitForSuccessor(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset()
+	)
+
+	foo, fooStarted, fooStopped := newTestElector(t, kubeClient, "foo")
+	bar, barStarted, _ := newTestElector(t, kubeClient, "bar")
+
+	require.NoError(t, foo.Start(ctx))
+	<-fooStarted
+
+	require.NoError(t, bar.Start(ctx))
+	require.NoError(t, foo.Stop(ctx))
+	<-fooStopped
+
+	waitDone := make(chan error, 1)
+
+	go func() {
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		waitDone <- foo.WaitForSuccessor(waitCtx)
+	}()
+
+	<-barStarted
+
+	// The successor leads, but didn't apply its configuration yet.
+	select {
+	case err := <-waitDone:
+		t.Fatalf("wait returned before the successor applied its configuration: %v", err)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	bar.MarkApplied(bar.GetTerm())
+
+	require.NoError(t, <-waitDone)
+}
+
+func TestElector_WaitForSuccessor_Timeout(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset()
+	)
+
+	foo, fooStarted, fooStopped := newTestElector(t, kubeClient, "foo")
+
+	require.NoError(t, foo.Start(ctx))
+	<-fooStarted
+
+	// A stale report doesn't count for the next term.
+	foo.MarkApplied(foo.GetTerm())
+
+	require.NoError(t, foo.Stop(ctx))
+	<-fooStopped
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	assert.ErrorIs(t, foo.WaitForSuccessor(waitCtx), context.DeadlineExceeded)
+}
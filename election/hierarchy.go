@@ -0,0 +1,115 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/klog/v2"
+)
+
+// GlobalGetter is implemented by the status of a hierarchical election.
+type GlobalGetter interface {
+	// Global returns the status of the global election, which only the local leader takes part to.
+	Global() Status
+}
+
+// Hierarchy runs a two level election: the members of a cluster compete for the local lease,
+// then the local leader competes for a global lease, usually living in a remote cluster, against the local leaders of the other clusters.
+// The hierarchy embeds the local election, the callbacks are only invoked for the global leadership.
+type Hierarchy struct {
+	*Elector
+
+	global *Elector
+
+	// globalMu serializes the start and the stop of the global election.
+	globalMu sync.Mutex
+}
+
+func NewHierarchy(
+	localCfg Config,
+	localClient kubernetes.Interface,
+	globalCfg Config,
+	globalClient kubernetes.Interface,
+	callbacks leaderelection.LeaderCallbacks,
+	reg prometheus.Registerer,
+) (*Hierarchy, error) {
+	// The metrics are shared by both electors, they are labeled by lease.
+	leaderMetrics := newLeaderMetrics(reg)
+	leaderelection.SetProvider(metricsProvider(func() leaderelection.LeaderMetric {
+		return leaderMetrics
+	}))
+
+	var (
+		h              Hierarchy
+		err            error
+		electorMetrics = newElectorMetrics(reg)
+	)
+
+	h.global, err = newElector(globalCfg, globalClient, callbacks, electorMetrics, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	h.Elector, err = newElector(
+		localCfg,
+		localClient,
+		leaderelection.LeaderCallbacks{
+			OnStartedLeading: h.startGlobal,
+			OnStoppedLeading: h.stopGlobal,
+		},
+		electorMetrics,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &h, nil
+}
+
+func (h *Hierarchy) Status() Status { return h }
+
+// Global returns the status of the global election.
+func (h *Hierarchy) Global() Status { return h.global }
+
+// MarkApplied reports on the global lease that the member applied the leader configuration for the given global term.
+func (h *Hierarchy) MarkApplied(term int64) { h.global.MarkApplied(term) }
+
+// startGlobal makes the local leader compete for the global lease until it stops leading locally.
+func (h *Hierarchy) startGlobal(ctx context.Context) {
+	h.globalMu.Lock()
+	defer h.globalMu.Unlock()
+
+	// The local leadership was lost before the global election could start.
+	if ctx.Err() != nil {
+		return
+	}
+
+	klog.InfoS("Leading locally, competing for the global lease", "lease", h.global.config.LeaseName)
+
+	if err := h.global.Start(context.Background()); err != nil {
+		klog.ErrorS(err, "Unable to join the global election")
+	}
+}
+
+// stopGlobal releases the global lease once the member stopped leading locally.
+func (h *Hierarchy) stopGlobal() {
+	h.globalMu.Lock()
+	defer h.globalMu.Unlock()
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), h.global.config.RenewDeadline)
+	defer cancel()
+
+	err := h.global.Stop(stopCtx)
+	switch {
+	case errors.Is(err, ErrNotRunning):
+	case err != nil:
+		klog.ErrorS(err, "Unable to leave the global election")
+	default:
+		klog.InfoS("Stopped leading locally, left the global election", "lease", h.global.config.LeaseName)
+	}
+}
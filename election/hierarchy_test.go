@@ -0,0 +1,116 @@
+package election_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection"
+
+	"github.com/jlevesy/prometheus-elector/election"
+)
+
+func TestHierarchy(t *testing.T) {
+	var (
+		ctx          = context.Background()
+		globalClient = kubefake.NewClientset()
+		localClients = map[string]*kubefake.Clientset{
+			"east": kubefake.NewClientset(),
+			"west": kubefake.NewClientset(),
+		}
+		members = make(map[string]*election.Hierarchy)
+	)
+
+	enforceLeaseResourceVersion(globalClient)
+
+	for _, localClient := range localClients {
+		enforceLeaseResourceVersion(localClient)
+	}
+
+	for _, member := range []struct{ cluster, memberID string }{
+		{cluster: "east", memberID: "east-0"},
+		{cluster: "east", memberID: "east-1"},
+		{cluster: "west", memberID: "west-0"},
+	} {
+		globalCfg := newTestConfig(member.cluster + "/" + member.memberID)
+		globalCfg.LeaseName = "global"
+
+		hierarchy, err := election.NewHierarchy(
+			newTestConfig(member.memberID),
+			localClients[member.cluster],
+			globalCfg,
+			globalClient,
+			leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(context.Context) {},
+				OnStoppedLeading: func() {},
+			},
+			nil,
+		)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			_ = hierarchy.Stop(context.Background())
+		})
+
+		require.NoError(t, hierarchy.Start(ctx))
+
+		members[member.memberID] = hierarchy
+	}
+
+	// Exactly one member leads globally, and it leads its cluster.
+	globalLeader := func() string {
+		var leaders []string
+
+		for memberID, hierarchy := range members {
+			global, ok := hierarchy.Status().(election.GlobalGetter)
+			require.True(t, ok)
+
+			if global.Global().IsLeader() {
+				if !hierarchy.Status().IsLeader() {
+					return ""
+				}
+
+				leaders = append(leaders, memberID)
+			}
+		}
+
+		if len(leaders) != 1 {
+			return ""
+		}
+
+		return leaders[0]
+	}
+
+	var firstLeader string
+
+	require.Eventually(t, func() bool {
+		firstLeader = globalLeader()
+		return firstLeader != ""
+	}, 10*time.Second, 100*time.Millisecond)
+
+	assert.Equal(t, 2, countLocalLeaders(members))
+
+	// The global leader leaving the election releases both leases, another local leader takes the global lease over.
+	require.NoError(t, members[firstLeader].Stop(ctx))
+	assert.False(t, members[firstLeader].Global().IsLeader())
+
+	require.Eventually(t, func() bool {
+		leader := globalLeader()
+		return leader != "" && leader != firstLeader
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+func countLocalLeaders(members map[string]*election.Hierarchy) int {
+	var leaders int
+
+	for _, hierarchy := range members {
+		if hierarchy.IsLeader() {
+			leaders++
+		}
+	}
+
+	return leaders
+}
@@ -0,0 +1,177 @@
+package election
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// HistoryEventAcquired means that the member acquired the lease.
+	HistoryEventAcquired = "acquired"
+	// HistoryEventResumed means that the member resumed a leadership it held before restarting.
+	HistoryEventResumed = "resumed"
+	// HistoryEventLost means that the member failed to renew the lease.
+	HistoryEventLost = "lost"
+	// HistoryEventReleased means that the member released the lease on purpose.
+	HistoryEventReleased = "released"
+	// HistoryEventNewLeader means that the member observed a new holder of the lease.
+	HistoryEventNewLeader = "new_leader"
+	// HistoryEventLeft means that the member left the election.
+	HistoryEventLeft = "left"
+)
+
+// HistoryEvent is an entry of the election history.
+type HistoryEvent struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Lease  string    `json:"lease"`
+	Leader string    `json:"leader,omitempty"`
+	Term   int64     `json:"term"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// HistoryGetter is implemented by the status of members recording their election history.
+type HistoryGetter interface {
+	// History returns the recorded events, oldest first.
+	History() []HistoryEvent
+}
+
+// History is a bounded record of the election events seen by the member.
+// If a path is set, the history is persisted to this file after every event and loaded back on startup.
+// A nil History records nothing.
+type History struct {
+	path string
+
+	mu     sync.Mutex
+	events []HistoryEvent
+	// next is the index of the slot to write the next event to, the oldest event once the buffer is full.
+	next int
+	full bool
+}
+
+func NewHistory(size int, path string) (*History, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("invalid history size %d, must be at least 1", size)
+	}
+
+	h := History{
+		path:   path,
+		events: make([]HistoryEvent, size),
+	}
+
+	if path == "" {
+		return &h, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &h, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the history file: %w", err)
+	}
+
+	var events []HistoryEvent
+
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("unable to decode the history file: %w", err)
+	}
+
+	for _, evt := range events {
+		h.append(evt)
+	}
+
+	return &h, nil
+}
+
+// Record appends an event to the history, evicting the oldest one if the history is full.
+func (h *History) Record(evt HistoryEvent) {
+	if h == nil {
+		return
+	}
+
+	if evt.Time.IsZero() {
+		evt.Time = time.Now()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.append(evt)
+
+	if h.path == "" {
+		return
+	}
+
+	if err := h.persist(); err != nil {
+		klog.ErrorS(err, "Unable to persist the election history", "path", h.path)
+	}
+}
+
+func (h *History) Events() []HistoryEvent {
+	if h == nil {
+		return []HistoryEvent{}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.eventsLocked()
+}
+
+// append must be called with h.mu held.
+func (h *History) append(evt HistoryEvent) {
+	h.events[h.next] = evt
+	h.next = (h.next + 1) % len(h.events)
+
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// eventsLocked must be called with h.mu held.
+func (h *History) eventsLocked() []HistoryEvent {
+	if !h.full {
+		return append([]HistoryEvent{}, h.events[:h.next]...)
+	}
+
+	events := make([]HistoryEvent, 0, len(h.events))
+	events = append(events, h.events[h.next:]...)
+
+	return append(events, h.events[:h.next]...)
+}
+
+// persist writes the history to a temporary file, then renames it to avoid leaving a partially written history.
+// It must be called with h.mu held.
+func (h *History) persist() error {
+	data, err := json.Marshal(h.eventsLocked())
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), h.path)
+}
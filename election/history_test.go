@@ -0,0 +1,112 @@
+package election_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/jlevesy/prometheus-elector/election"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	history, err := election.NewHistory(3, path)
+	require.NoError(t, err)
+
+	assert.Empty(t, history.Events())
+
+	for term := range int64(5) {
+		history.Record(election.HistoryEvent{Type: election.HistoryEventAcquired, Term: term})
+	}
+
+	assertTerms := func(t *testing.T, history *election.History, want ...int64) {
+		t.Helper()
+
+		var got []int64
+		for _, evt := range history.Events() {
+			assert.False(t, evt.Time.IsZero())
+			got = append(got, evt.Term)
+		}
+
+		assert.Equal(t, want, got)
+	}
+
+	// Only the most recent events are kept, oldest first.
+	assertTerms(t, history, 2, 3, 4)
+
+	// The history is loaded back from the file.
+	loaded, err := election.NewHistory(2, path)
+	require.NoError(t, err)
+
+	assertTerms(t, loaded, 3, 4)
+
+	var disabled *election.History
+	disabled.Record(election.HistoryEvent{Type: election.HistoryEventAcquired})
+	assert.Empty(t, disabled.Events())
+}
+
+func TestElector_History(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset()
+		cfg        = newTestConfig("foo")
+	)
+
+	history, err := election.NewHistory(10, "")
+	require.NoError(t, err)
+
+	cfg.History = history
+
+	foo, fooStarted, fooStopped := newTestElectorWithConfig(t, kubeClient, cfg)
+	bar, barStarted, _ := newTestElector(t, kubeClient, "bar")
+
+	require.NoError(t, foo.Start(ctx))
+	<-fooStarted
+
+	require.NoError(t, bar.Start(ctx))
+	require.NoError(t, foo.StepDown(ctx))
+	<-fooStopped
+	<-barStarted
+
+	type event struct {
+		eventType, leader, reason string
+	}
+
+	historyEvents := func() []event {
+		var events []event
+
+		for _, evt := range foo.History() {
+			assert.Equal(t, "test", evt.Lease)
+			events = append(events, event{eventType: evt.Type, leader: evt.Leader, reason: evt.Reason})
+		}
+
+		return events
+	}
+
+	assert.Eventually(
+		t,
+		func() bool { return len(historyEvents()) == 4 },
+		5*time.Second,
+		100*time.Millisecond,
+	)
+
+	require.NoError(t, foo.Stop(ctx))
+
+	assert.Equal(
+		t,
+		[]event{
+			{eventType: election.HistoryEventNewLeader, leader: "foo"},
+			{eventType: election.HistoryEventAcquired, leader: "foo"},
+			{eventType: election.HistoryEventReleased, leader: "foo", reason: "stepped down"},
+			{eventType: election.HistoryEventNewLeader, leader: "bar"},
+			{eventType: election.HistoryEventLeft, leader: "bar", reason: "left the election"},
+		},
+		historyEvents(),
+	)
+}
@@ -0,0 +1,237 @@
+package election
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// leaseLock is a resourcelock.Interface backed by a Lease object.
+// It behaves like resourcelock.LeaseLock, but it also allows the elector
+// to refuse acquiring the lease and to carry annotations along the lease updates.
+type leaseLock struct {
+	leaseMeta metav1.ObjectMeta
+	client    coordinationv1client.LeasesGetter
+	identity  string
+
+	// acquire wraps any attempt of taking over the lease from another member.
+	// It can abort the attempt by returning an error without calling write.
+	acquire func(lease *coordinationv1.Lease, write func() error) error
+	// renew wraps any renewal of the lease by its holder.
+	// It can abort the renewal by returning an error without calling write.
+	renew func(lease *coordinationv1.Lease, write func() error) error
+	// observe is called every time the lease is read or written.
+	observe func(lease *coordinationv1.Lease)
+	// renewed is called with the duration of every successful renewal of the lease by its holder.
+	renewed func(duration time.Duration)
+
+	mu                 sync.Mutex
+	lease              *coordinationv1.Lease
+	pendingAnnotations map[string]string
+}
+
+// Get returns the election record from a Lease spec.
+func (l *leaseLock) Get(ctx context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	lease, err := l.client.Leases(l.leaseMeta.Namespace).Get(ctx, l.leaseMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	l.mu.Lock()
+	l.lease = lease
+	l.mu.Unlock()
+
+	l.observe(lease)
+
+	record := resourcelock.LeaseSpecToLeaderElectionRecord(&lease.Spec)
+	recordBytes, err := json.Marshal(*record)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return record, recordBytes, nil
+}
+
+// Create attempts to create a Lease.
+func (l *leaseLock) Create(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      l.leaseMeta.Name,
+			Namespace: l.leaseMeta.Namespace,
+		},
+		Spec: resourcelock.LeaderElectionRecordToLeaseSpec(&ler),
+	}
+
+	return l.acquire(lease, func() error {
+		applied := l.applyPendingAnnotations(lease)
+
+		created, err := l.client.Leases(l.leaseMeta.Namespace).Create(ctx, lease, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+
+		l.written(created, applied)
+
+		return nil
+	})
+}
+
+// Update will update an existing Lease spec.
+func (l *leaseLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	l.mu.Lock()
+	if l.lease == nil {
+		l.mu.Unlock()
+		return errors.New("lease not initialized, call get or create first")
+	}
+
+	lease := l.lease.DeepCopy()
+	l.mu.Unlock()
+
+	write := func() error {
+		strategy := lease.Spec.Strategy
+
+		lease.Spec = resourcelock.LeaderElectionRecordToLeaseSpec(&ler)
+
+		// The strategy is owned by the coordinator, releasing the lease must not end the coordination.
+		if lease.Spec.Strategy == nil {
+			lease.Spec.Strategy = strategy
+		}
+		applied := l.applyPendingAnnotations(lease)
+
+		updated, err := l.client.Leases(l.leaseMeta.Namespace).Update(ctx, lease, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+
+		l.written(updated, applied)
+
+		return nil
+	}
+
+	if isAcquisition(lease, ler, l.identity) {
+		return l.acquire(lease, write)
+	}
+
+	if ler.HolderIdentity != l.identity {
+		return write()
+	}
+
+	return l.renew(lease, func() error {
+		startTime := time.Now()
+
+		if err := write(); err != nil {
+			return err
+		}
+
+		l.renewed(time.Since(startTime))
+
+		return nil
+	})
+}
+
+// written records a successful write of the lease.
+func (l *leaseLock) written(lease *coordinationv1.Lease, appliedAnnotations map[string]string) {
+	l.mu.Lock()
+	l.lease = lease
+	l.clearPendingAnnotations(appliedAnnotations)
+	l.mu.Unlock()
+
+	l.observe(lease)
+}
+
+// RecordEvent is a no-op, events are not supported by this lock.
+func (l *leaseLock) RecordEvent(string) {}
+
+// Describe is used to convert details on current resource lock
+// into a string.
+func (l *leaseLock) Describe() string {
+	return fmt.Sprintf("%v/%v", l.leaseMeta.Namespace, l.leaseMeta.Name)
+}
+
+// Identity returns the Identity of the lock.
+func (l *leaseLock) Identity() string {
+	return l.identity
+}
+
+// setPendingAnnotations registers annotations to set on the next lease write.
+// An empty value removes the annotation.
+func (l *leaseLock) setPendingAnnotations(annotations map[string]string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.pendingAnnotations == nil {
+		l.pendingAnnotations = make(map[string]string, len(annotations))
+	}
+
+	for k, v := range annotations {
+		l.pendingAnnotations[k] = v
+	}
+}
+
+// observedLease returns a copy of the last observed lease, nil if never observed.
+func (l *leaseLock) observedLease() *coordinationv1.Lease {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.lease == nil {
+		return nil
+	}
+
+	return l.lease.DeepCopy()
+}
+
+// applyPendingAnnotations sets the pending annotations on the given lease and returns what has been applied.
+// Pending annotations are kept until the write succeeds.
+func (l *leaseLock) applyPendingAnnotations(lease *coordinationv1.Lease) map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pendingAnnotations) == 0 {
+		return nil
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = make(map[string]string, len(l.pendingAnnotations))
+	}
+
+	applied := make(map[string]string, len(l.pendingAnnotations))
+
+	for k, v := range l.pendingAnnotations {
+		applied[k] = v
+
+		if v == "" {
+			delete(lease.Annotations, k)
+			continue
+		}
+
+		lease.Annotations[k] = v
+	}
+
+	return applied
+}
+
+// clearPendingAnnotations must be called with l.mu held.
+func (l *leaseLock) clearPendingAnnotations(applied map[string]string) {
+	for k, v := range applied {
+		if l.pendingAnnotations[k] == v {
+			delete(l.pendingAnnotations, k)
+		}
+	}
+}
+
+// isAcquisition tells if writing the given record means taking over the lease.
+func isAcquisition(lease *coordinationv1.Lease, ler resourcelock.LeaderElectionRecord, identity string) bool {
+	if ler.HolderIdentity != identity {
+		return false
+	}
+
+	return lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != identity
+}
@@ -0,0 +1,296 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// electionLabel ties a member heartbeat lease to its election.
+	electionLabel = "prometheus-elector.io/election"
+
+	memberStateAnnotation      = "prometheus-elector.io/member-state"
+	memberHealthAnnotation     = "prometheus-elector.io/member-health"
+	memberConfigHashAnnotation = "prometheus-elector.io/member-config-hash"
+	memberSourceHashAnnotation = "prometheus-elector.io/member-source-hash"
+	memberFitnessAnnotation    = "prometheus-elector.io/member-fitness"
+	// memberSourceGenerationAnnotation is only set with the configuration gating, see configGating.
+	memberSourceGenerationAnnotation = "prometheus-elector.io/member-source-generation"
+)
+
+const (
+	MemberStateLeader   = "leader"
+	MemberStateFollower = "follower"
+	MemberStateCordoned = "cordoned"
+	// MemberStateLeft means that the member is not taking part to the election, for instance because its Prometheus is unhealthy.
+	MemberStateLeft = "left"
+
+	MemberHealthUnknown   = "unknown"
+	MemberHealthHealthy   = "healthy"
+	MemberHealthUnhealthy = "unhealthy"
+)
+
+// Member is the last heartbeat of a member of the election.
+type Member struct {
+	ID         string
+	State      string
+	Health     string
+	ConfigHash string
+	// SourceHash is the hash of the source configuration, which is the same for all the roles.
+	SourceHash string
+	// SourceGeneration orders the source configurations with the configuration gating, 0 if unknown.
+	SourceGeneration int64
+	// Fitness is the fitness score of the member, nil if it doesn't report any.
+	Fitness       *float64
+	LastHeartbeat time.Time
+	// Stale is set when the member didn't send any heartbeat for longer than MemberStaleTimeout.
+	Stale bool
+}
+
+type MemberLister interface {
+	Members(ctx context.Context) ([]Member, error)
+}
+
+// heartbeat maintains a lease per member, named `<LeaseName>-member-<MemberID>`, describing its state.
+type heartbeat struct {
+	config Config
+	leases coordinationv1client.LeaseInterface
+	state  func() string
+	health atomic.Pointer[string]
+	// gating is only set with the configuration gating.
+	gating *configGating
+}
+
+func newHeartbeat(cfg Config, k8sClient kubernetes.Interface, state func() string) *heartbeat {
+	h := heartbeat{
+		config: cfg,
+		leases: k8sClient.CoordinationV1().Leases(cfg.LeaseNamespace),
+		state:  state,
+	}
+
+	if cfg.ConfigGating {
+		h.gating = newConfigGating(cfg, k8sClient)
+	}
+
+	h.setHealth(MemberHealthUnknown)
+
+	return &h
+}
+
+// run sends a heartbeat every HeartbeatPeriod until the given context is done, then deletes the heartbeat lease.
+func (h *heartbeat) run(ctx context.Context) error {
+	if h.config.HeartbeatPeriod <= 0 {
+		return nil
+	}
+
+	if h.gating != nil {
+		gatingCtx, cancel := context.WithCancel(ctx)
+		gatingDone := make(chan struct{})
+
+		go func() {
+			defer close(gatingDone)
+			h.gating.run(gatingCtx)
+		}()
+
+		defer func() {
+			cancel()
+			<-gatingDone
+		}()
+	}
+
+	ticker := time.NewTicker(h.config.HeartbeatPeriod)
+	defer ticker.Stop()
+
+	for {
+		if err := h.beat(ctx); err != nil && ctx.Err() == nil {
+			klog.ErrorS(err, "Unable to send the member heartbeat")
+		}
+
+		select {
+		case <-ctx.Done():
+			return h.remove()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *heartbeat) beat(ctx context.Context) error {
+	var (
+		now         = metav1.NewMicroTime(time.Now())
+		annotations = map[string]string{
+			memberStateAnnotation:      h.state(),
+			memberHealthAnnotation:     *h.health.Load(),
+			memberConfigHashAnnotation: h.configHash(),
+			memberSourceHashAnnotation: h.sourceHash(),
+		}
+	)
+
+	if h.config.Fitness != nil {
+		if fitness, ok := h.config.Fitness(); ok {
+			annotations[memberFitnessAnnotation] = strconv.FormatFloat(fitness, 'f', 3, 64)
+		}
+	}
+
+	if h.gating != nil {
+		if generation := h.gating.generation(); generation > 0 {
+			annotations[memberSourceGenerationAnnotation] = strconv.FormatInt(generation, 10)
+		}
+	}
+
+	lease, err := h.leases.Get(ctx, h.leaseName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = h.leases.Create(
+			ctx,
+			&coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        h.leaseName(),
+					Namespace:   h.config.LeaseNamespace,
+					Labels:      map[string]string{electionLabel: h.config.LeaseName},
+					Annotations: annotations,
+				},
+				Spec: coordinationv1.LeaseSpec{
+					HolderIdentity:       strPtr(h.config.MemberID),
+					LeaseDurationSeconds: int32Ptr(int32(h.config.MemberStaleTimeout / time.Second)),
+					RenewTime:            &now,
+				},
+			},
+			metav1.CreateOptions{},
+		)
+
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if lease.Annotations == nil {
+		lease.Annotations = make(map[string]string, len(annotations))
+	}
+
+	for k, v := range annotations {
+		lease.Annotations[k] = v
+	}
+
+	lease.Spec.RenewTime = &now
+
+	_, err = h.leases.Update(ctx, lease, metav1.UpdateOptions{})
+
+	return err
+}
+
+func (h *heartbeat) remove() error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.config.RenewDeadline)
+	defer cancel()
+
+	err := h.leases.Delete(ctx, h.leaseName(), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("unable to delete the member heartbeat: %w", err)
+	}
+
+	return nil
+}
+
+func (h *heartbeat) setHealthy(healthy bool) {
+	if healthy {
+		h.setHealth(MemberHealthHealthy)
+		return
+	}
+
+	h.setHealth(MemberHealthUnhealthy)
+}
+
+func (h *heartbeat) setHealth(health string) {
+	h.health.Store(&health)
+}
+
+func (h *heartbeat) configHash() string {
+	if h.config.ConfigHash == nil {
+		return ""
+	}
+
+	return h.config.ConfigHash()
+}
+
+func (h *heartbeat) sourceHash() string {
+	if h.config.SourceHash == nil {
+		return ""
+	}
+
+	return h.config.SourceHash()
+}
+
+func (h *heartbeat) leaseName() string {
+	return h.config.LeaseName + "-member-" + h.config.MemberID
+}
+
+// listMembers returns the last heartbeat of every member of the election, sorted by member ID.
+func listMembers(ctx context.Context, leases coordinationv1client.LeaseInterface, cfg Config) ([]Member, error) {
+	leaseList, err := leases.List(
+		ctx,
+		metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(labels.Set{electionLabel: cfg.LeaseName}).String(),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		now     = time.Now()
+		members = make([]Member, 0, len(leaseList.Items))
+	)
+
+	for i := range leaseList.Items {
+		if member, ok := memberFromLease(&leaseList.Items[i], now, cfg); ok {
+			members = append(members, member)
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+
+	return members, nil
+}
+
+// memberFromLease reads the heartbeat of a member from its lease, it returns false if the lease has no holder.
+func memberFromLease(lease *coordinationv1.Lease, now time.Time, cfg Config) (Member, bool) {
+	if lease.Spec.HolderIdentity == nil {
+		return Member{}, false
+	}
+
+	member := Member{
+		ID:         *lease.Spec.HolderIdentity,
+		State:      lease.Annotations[memberStateAnnotation],
+		Health:     lease.Annotations[memberHealthAnnotation],
+		ConfigHash: lease.Annotations[memberConfigHashAnnotation],
+		SourceHash: lease.Annotations[memberSourceHashAnnotation],
+	}
+
+	if fitness, err := strconv.ParseFloat(lease.Annotations[memberFitnessAnnotation], 64); err == nil {
+		member.Fitness = &fitness
+	}
+
+	if generation, err := strconv.ParseInt(lease.Annotations[memberSourceGenerationAnnotation], 10, 64); err == nil {
+		member.SourceGeneration = generation
+	}
+
+	if lease.Spec.RenewTime != nil {
+		member.LastHeartbeat = lease.Spec.RenewTime.Time
+	}
+
+	member.Stale = now.Sub(member.LastHeartbeat) > cfg.MemberStaleTimeout
+
+	return member, true
+}
@@ -0,0 +1,100 @@
+package election_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jlevesy/prometheus-elector/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestElector_Members(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		kubeClient  = kubefake.NewClientset(
+			// A member that stopped sending heartbeats a while ago.
+			&coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-member-biz",
+					Namespace: "test",
+					Labels:    map[string]string{"prometheus-elector.io/election": "test"},
+					Annotations: map[string]string{
+						"prometheus-elector.io/member-state":  "follower",
+						"prometheus-elector.io/member-health": "healthy",
+					},
+				},
+				Spec: coordinationv1.LeaseSpec{
+					HolderIdentity: strPtr("biz"),
+					RenewTime:      &metav1.MicroTime{Time: time.Now().Add(-time.Hour)},
+				},
+			},
+		)
+	)
+
+	defer cancel()
+
+	newMemberConfig := func(memberID string) election.Config {
+		cfg := newTestConfig(memberID)
+		cfg.HeartbeatPeriod = 100 * time.Millisecond
+		cfg.MemberStaleTimeout = 10 * time.Second
+		cfg.ConfigHash = func() string { return memberID + "-hash" }
+		// Only foo computed its fitness.
+		cfg.Fitness = func() (float64, bool) { return 0.8, memberID == "foo" }
+
+		return cfg
+	}
+
+	foo, fooStarted, _ := newTestElectorWithConfig(t, kubeClient, newMemberConfig("foo"))
+	bar, _, _ := newTestElectorWithConfig(t, kubeClient, newMemberConfig("bar"))
+
+	foo.SetHealthy(true)
+	bar.SetHealthy(false)
+
+	require.NoError(t, foo.Start(ctx))
+	<-fooStarted
+
+	fooHeartbeatDone := make(chan error)
+	go func() { fooHeartbeatDone <- foo.RunHeartbeat(ctx) }()
+
+	barCtx, barCancel := context.WithCancel(ctx)
+	barHeartbeatDone := make(chan error)
+	go func() { barHeartbeatDone <- bar.RunHeartbeat(barCtx) }()
+
+	fooFitness := 0.8
+	wantMembers := []election.Member{
+		{ID: "bar", State: election.MemberStateLeft, Health: election.MemberHealthUnhealthy, ConfigHash: "bar-hash"},
+		{ID: "biz", State: election.MemberStateFollower, Health: election.MemberHealthHealthy, Stale: true},
+		{ID: "foo", State: election.MemberStateLeader, Health: election.MemberHealthHealthy, ConfigHash: "foo-hash", Fitness: &fooFitness},
+	}
+
+	assert.Eventually(t, func() bool {
+		members, err := foo.Members(ctx)
+		require.NoError(t, err)
+
+		return assert.ObjectsAreEqual(wantMembers, withoutHeartbeats(members))
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// A member leaving removes its heartbeat.
+	barCancel()
+	require.NoError(t, <-barHeartbeatDone)
+
+	members, err := foo.Members(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, wantMembers[1:], withoutHeartbeats(members))
+
+	cancel()
+	require.NoError(t, <-fooHeartbeatDone)
+}
+
+func withoutHeartbeats(members []election.Member) []election.Member {
+	for i := range members {
+		members[i].LastHeartbeat = time.Time{}
+	}
+
+	return members
+}
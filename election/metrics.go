@@ -1,6 +1,8 @@
 package election
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"k8s.io/client-go/tools/leaderelection"
@@ -48,3 +50,149 @@ func (m *leaderMetrics) Off(name string) {
 }
 
 func (m *leaderMetrics) SlowpathExercised(name string) {}
+
+type electorMetrics struct {
+	cordoned           prometheus.Gauge
+	term               *prometheus.GaugeVec
+	transitions        *prometheus.CounterVec
+	leadershipDuration *prometheus.HistogramVec
+	renewDuration      *prometheus.HistogramVec
+	leaderless         *prometheus.GaugeVec
+	leaderInfo         *prometheus.GaugeVec
+	handoverWait       *prometheus.HistogramVec
+}
+
+func newElectorMetrics(r prometheus.Registerer) *electorMetrics {
+	return &electorMetrics{
+		cordoned: promauto.With(r).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "prometheus_elector",
+				Name:      "election_cordoned",
+				Help:      "Set to 1 when the member is cordoned out of the election",
+			},
+		),
+		term: promauto.With(r).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "prometheus_elector",
+				Name:      "election_term",
+				Help:      "Term of the current leadership, incremented every time the lease is acquired",
+			},
+			[]string{"lease"},
+		),
+		transitions: promauto.With(r).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "prometheus_elector",
+				Name:      "election_transitions_total",
+				Help:      "The total amount of times the member started or stopped leading, by direction",
+			},
+			[]string{"lease", "direction"},
+		),
+		leadershipDuration: promauto.With(r).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "prometheus_elector",
+				Name:      "election_leadership_duration_seconds",
+				Help:      "How long the member led before it stopped leading",
+				// From one second to three days.
+				Buckets: prometheus.ExponentialBuckets(1, 4, 10),
+			},
+			[]string{"lease"},
+		),
+		renewDuration: promauto.With(r).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "prometheus_elector",
+				Name:      "election_lease_renew_duration_seconds",
+				Help:      "The time it took the leader to renew the lease",
+			},
+			[]string{"lease"},
+		),
+		leaderless: promauto.With(r).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "prometheus_elector",
+				Name:      "election_leaderless_duration_seconds",
+				Help:      "Duration of the current or last period without any known leader, from the observed leader loss to the observed new leader",
+			},
+			[]string{"lease"},
+		),
+		leaderInfo: promauto.With(r).NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "prometheus_elector",
+				Name:      "election_leader_info",
+				Help:      "Set to 1 for the current leader observed by the member",
+			},
+			[]string{"lease", "leader"},
+		),
+		handoverWait: promauto.With(r).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "prometheus_elector",
+				Name:      "election_handover_wait_duration_seconds",
+				Help:      "How long a leaving leader waited for the next leader to apply its configuration, by outcome",
+				// From half a second to about a minute.
+				Buckets: prometheus.ExponentialBuckets(0.5, 2, 8),
+			},
+			[]string{"lease", "outcome"},
+		),
+	}
+}
+
+func (m *electorMetrics) startedLeading(lease string) {
+	m.transitions.WithLabelValues(lease, "leader").Inc()
+}
+
+func (m *electorMetrics) stoppedLeading(lease string, leadingSince time.Time) {
+	m.transitions.WithLabelValues(lease, "follower").Inc()
+	m.leadershipDuration.WithLabelValues(lease).Observe(time.Since(leadingSince).Seconds())
+}
+
+func (m *electorMetrics) observeRenewDuration(lease string, duration time.Duration) {
+	m.renewDuration.WithLabelValues(lease).Observe(duration.Seconds())
+}
+
+func (m *electorMetrics) observeHandoverWait(lease string, duration time.Duration, outcome string) {
+	m.handoverWait.WithLabelValues(lease, outcome).Observe(duration.Seconds())
+}
+
+func (m *electorMetrics) setLeaderless(lease string, duration time.Duration) {
+	m.leaderless.WithLabelValues(lease).Set(duration.Seconds())
+}
+
+// setLeader sets the leader of the given lease, an empty leader means that there's none.
+func (m *electorMetrics) setLeader(lease, leader string) {
+	m.leaderInfo.DeletePartialMatch(prometheus.Labels{"lease": lease})
+
+	if leader != "" {
+		m.leaderInfo.WithLabelValues(lease, leader).Set(1.0)
+	}
+}
+
+func (m *electorMetrics) setCordoned(cordoned bool) {
+	if cordoned {
+		m.cordoned.Set(1.0)
+		return
+	}
+
+	m.cordoned.Set(0.0)
+}
+
+func (m *electorMetrics) setTerm(lease string, term int64) {
+	m.term.WithLabelValues(lease).Set(float64(term))
+}
+
+type slotMetrics struct {
+	heldSlot prometheus.Gauge
+}
+
+func newSlotMetrics(r prometheus.Registerer) *slotMetrics {
+	return &slotMetrics{
+		heldSlot: promauto.With(r).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "prometheus_elector",
+				Name:      "election_held_slot",
+				Help:      "Index of the slot held by the member, -1 if it holds none",
+			},
+		),
+	}
+}
+
+func (m *slotMetrics) setHeld(slot int) {
+	m.heldSlot.Set(float64(slot))
+}
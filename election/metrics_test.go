@@ -0,0 +1,93 @@
+package election_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection"
+
+	"github.com/jlevesy/prometheus-elector/election"
+)
+
+func TestElector_Metrics(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset()
+		reg        = prometheus.NewRegistry()
+		fooStarted = make(chan struct{}, 1)
+		fooStopped = make(chan struct{}, 1)
+	)
+
+	// The client-go leader metrics provider is global and set once, create an elector without registry first.
+	bar, barStarted, _ := newTestElector(t, kubeClient, "bar")
+
+	foo, err := election.New(
+		newTestConfig("foo"),
+		kubeClient,
+		leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) { fooStarted <- struct{}{} },
+			OnStoppedLeading: func() {
+				select {
+				case fooStopped <- struct{}{}:
+				default:
+				}
+			},
+		},
+		reg,
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = foo.Stop(context.Background()) })
+
+	require.NoError(t, foo.Start(ctx))
+	<-fooStarted
+
+	// Let the leader renew the lease at least once.
+	assert.Eventually(
+		t,
+		func() bool {
+			return testutil.CollectAndCount(reg, "prometheus_elector_election_lease_renew_duration_seconds") > 0
+		},
+		5*time.Second,
+		100*time.Millisecond,
+	)
+
+	require.NoError(t, bar.Start(ctx))
+	require.NoError(t, foo.StepDown(ctx))
+	<-fooStopped
+	<-barStarted
+
+	const wantMetrics = `
+# HELP prometheus_elector_election_leader_info Set to 1 for the current leader observed by the member
+# TYPE prometheus_elector_election_leader_info gauge
+prometheus_elector_election_leader_info{leader="bar",lease="test"} 1
+# HELP prometheus_elector_election_transitions_total The total amount of times the member started or stopped leading, by direction
+# TYPE prometheus_elector_election_transitions_total counter
+prometheus_elector_election_transitions_total{direction="follower",lease="test"} 1
+prometheus_elector_election_transitions_total{direction="leader",lease="test"} 1
+`
+
+	assert.Eventually(
+		t,
+		func() bool {
+			return testutil.GatherAndCompare(
+				reg,
+				strings.NewReader(wantMetrics),
+				"prometheus_elector_election_leader_info",
+				"prometheus_elector_election_transitions_total",
+			) == nil
+		},
+		5*time.Second,
+		100*time.Millisecond,
+	)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(reg, "prometheus_elector_election_leadership_duration_seconds"))
+	assert.Equal(t, 1, testutil.CollectAndCount(reg, "prometheus_elector_election_leaderless_duration_seconds"))
+}
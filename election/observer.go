@@ -0,0 +1,116 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	coordinationv1listers "k8s.io/client-go/listers/coordination/v1"
+	"k8s.io/klog/v2"
+)
+
+var errCacheNotSynced = errors.New("unable to sync the lease cache")
+
+// Observer follows the election by watching the lease, without ever competing for it.
+type Observer struct {
+	config Config
+
+	informerFactory informers.SharedInformerFactory
+	leaseLister     coordinationv1listers.LeaseNamespaceLister
+	leases          coordinationv1client.LeaseInterface
+}
+
+func NewObserver(cfg Config, k8sClient kubernetes.Interface) *Observer {
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(
+		k8sClient,
+		0,
+		informers.WithNamespace(cfg.LeaseNamespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", cfg.LeaseName).String()
+		}),
+	)
+
+	return &Observer{
+		config:          cfg,
+		informerFactory: informerFactory,
+		leaseLister:     informerFactory.Coordination().V1().Leases().Lister().Leases(cfg.LeaseNamespace),
+		leases:          k8sClient.CoordinationV1().Leases(cfg.LeaseNamespace),
+	}
+}
+
+// Run watches the lease until the given context is done.
+func (o *Observer) Run(ctx context.Context) error {
+	o.informerFactory.Start(ctx.Done())
+	defer o.informerFactory.Shutdown()
+
+	for _, synced := range o.informerFactory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return errCacheNotSynced
+		}
+	}
+
+	klog.InfoS("Observing the election", "lease", o.config.LeaseNamespace+"/"+o.config.LeaseName)
+
+	<-ctx.Done()
+
+	return nil
+}
+
+func (o *Observer) Status() Status { return o }
+
+// IsLeader always returns false, an observer never leads.
+func (o *Observer) IsLeader() bool { return false }
+
+// GetLeader returns the holder of the lease, or an empty string once it expired.
+func (o *Observer) GetLeader() string {
+	lease := o.lease()
+	if lease == nil {
+		return ""
+	}
+
+	return activeHolder(lease, time.Now())
+}
+
+// IsCordoned always returns false, an observer never takes part to the election.
+func (o *Observer) IsCordoned() bool { return false }
+
+func (o *Observer) GetZone() string { return o.config.Zone }
+
+func (o *Observer) GetLeaderZone() string {
+	lease := o.lease()
+	if lease == nil {
+		return ""
+	}
+
+	return lease.Annotations[holderZoneAnnotation]
+}
+
+func (o *Observer) GetTerm() int64 { return leaseTerm(o.lease()) }
+
+func (o *Observer) Members(ctx context.Context) ([]Member, error) {
+	return listMembers(ctx, o.leases, o.config)
+}
+
+func (o *Observer) lease() *coordinationv1.Lease {
+	lease, err := o.leaseLister.Get(o.config.LeaseName)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Unable to get the lease from the cache")
+		}
+
+		return nil
+	}
+
+	return lease
+}
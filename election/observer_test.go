@@ -0,0 +1,68 @@
+package election_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jlevesy/prometheus-elector/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestObserver(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		kubeClient  = kubefake.NewClientset()
+		observer    = election.NewObserver(newTestConfig("observer"), kubeClient)
+		observerErr = make(chan error)
+	)
+
+	defer cancel()
+
+	go func() {
+		observerErr <- observer.Run(ctx)
+	}()
+
+	assert.Empty(t, observer.GetLeader())
+
+	elector, startedLeading, _ := newTestElector(t, kubeClient, "foo")
+
+	require.NoError(t, elector.Start(ctx))
+	<-startedLeading
+
+	assert.Eventually(t, func() bool {
+		return observer.GetLeader() == "foo"
+	}, 5*time.Second, 100*time.Millisecond)
+
+	assert.False(t, observer.IsLeader())
+
+	cancel()
+	require.NoError(t, <-observerErr)
+}
+
+func TestObserver_ExpiredLease(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		// foo still holds the lease, but stopped renewing it.
+		kubeClient  = kubefake.NewClientset(heldLease("test", "foo", time.Now().Add(-time.Minute), 3))
+		observer    = election.NewObserver(newTestConfig("observer"), kubeClient)
+		observerErr = make(chan error)
+	)
+
+	defer cancel()
+
+	go func() {
+		observerErr <- observer.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return observer.GetTerm() == 3
+	}, 5*time.Second, 100*time.Millisecond)
+
+	assert.Empty(t, observer.GetLeader())
+
+	cancel()
+	require.NoError(t, <-observerErr)
+}
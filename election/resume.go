@@ -0,0 +1,51 @@
+package election
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LookupHeldTerm tells if the member is the active holder of the lease, and returns the term of its leadership.
+// It allows a member restarting while it still holds the lease to apply the leader configuration right away,
+// the elector then resumes the leadership without releasing and acquiring the lease again.
+func LookupHeldTerm(ctx context.Context, k8sClient kubernetes.Interface, cfg Config) (int64, bool, error) {
+	lease, err := k8sClient.CoordinationV1().Leases(cfg.LeaseNamespace).Get(ctx, cfg.LeaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to get lease %s/%s: %w", cfg.LeaseNamespace, cfg.LeaseName, err)
+	}
+
+	if activeHolder(lease, time.Now()) != cfg.MemberID {
+		return 0, false, nil
+	}
+
+	return leaseTerm(lease), true, nil
+}
+
+// LookupHeldSlot tells if the member is the active holder of one of the slot leases of a multi slot election,
+// and returns the slot with the term of its leadership.
+func LookupHeldSlot(ctx context.Context, k8sClient kubernetes.Interface, cfg Config, slots int) (int, int64, bool, error) {
+	for slot := range slots {
+		slotCfg := cfg
+		slotCfg.LeaseName = slotLeaseName(cfg.LeaseName, slot)
+
+		term, held, err := LookupHeldTerm(ctx, k8sClient, slotCfg)
+		if err != nil {
+			return noSlot, 0, false, err
+		}
+
+		if held {
+			return slot, term, true, nil
+		}
+	}
+
+	return noSlot, 0, false, nil
+}
@@ -0,0 +1,163 @@
+package election_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"github.com/jlevesy/prometheus-elector/election"
+)
+
+func TestLookupHeldTerm(t *testing.T) {
+	for _, testCase := range []struct {
+		desc     string
+		lease    *coordinationv1.Lease
+		wantHeld bool
+		wantTerm int64
+	}{
+		{
+			desc:     "no lease",
+			wantHeld: false,
+		},
+		{
+			desc:     "held by the member",
+			lease:    heldLease("test", "foo", time.Now(), 4),
+			wantHeld: true,
+			wantTerm: 4,
+		},
+		{
+			desc:     "expired",
+			lease:    heldLease("test", "foo", time.Now().Add(-time.Minute), 4),
+			wantHeld: false,
+		},
+		{
+			desc:     "held by another member",
+			lease:    heldLease("test", "bar", time.Now(), 4),
+			wantHeld: false,
+		},
+	} {
+		t.Run(testCase.desc, func(t *testing.T) {
+			kubeClient := kubefake.NewClientset()
+
+			if testCase.lease != nil {
+				_, err := kubeClient.CoordinationV1().Leases("test").Create(context.Background(), testCase.lease, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			term, held, err := election.LookupHeldTerm(context.Background(), kubeClient, newTestConfig("foo"))
+			require.NoError(t, err)
+
+			assert.Equal(t, testCase.wantHeld, held)
+			assert.Equal(t, testCase.wantTerm, term)
+		})
+	}
+}
+
+func TestElector_ResumesHeldLease(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset()
+		cfg        = newTestConfig("foo")
+		lease      = heldLease("test", "foo", time.Now(), 4)
+	)
+
+	lease.Annotations = map[string]string{"prometheus-elector.io/applied-term": "4"}
+
+	_, err := kubeClient.CoordinationV1().Leases("test").Create(ctx, lease, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	history, err := election.NewHistory(10, "")
+	require.NoError(t, err)
+
+	cfg.History = history
+
+	elector, startedLeading, _ := newTestElectorWithConfig(t, kubeClient, cfg)
+
+	require.NoError(t, elector.Start(ctx))
+	<-startedLeading
+
+	// The leadership goes on with the same term, the leader configuration is still applied.
+	assert.Equal(t, int64(4), elector.GetTerm())
+
+	got, err := kubeClient.CoordinationV1().Leases("test").Get(ctx, "test", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "4", got.Annotations["prometheus-elector.io/applied-term"])
+
+	events := history.Events()
+	require.NotEmpty(t, events)
+	assert.Equal(t, election.HistoryEventResumed, events[len(events)-1].Type)
+}
+
+func TestSlots_ResumesHeldSlot(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset()
+		started    = make(chan int, 1)
+	)
+
+	_, err := kubeClient.CoordinationV1().Leases("test").Create(ctx, heldLease("test-1", "foo", time.Now(), 2), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Reading the held slot is slower, letting the free slot be tried first.
+	kubeClient.PrependReactor("get", "leases", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		if action.(kubetesting.GetAction).GetName() == "test-1" {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		return false, nil, nil
+	})
+
+	slots, err := election.NewSlots(
+		newTestConfig("foo"),
+		2,
+		kubeClient,
+		election.SlotCallbacks{
+			OnStartedLeading: func(_ context.Context, slot int) { started <- slot },
+			OnStoppedLeading: func(int) {},
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = slots.Stop(context.Background())
+	})
+
+	require.NoError(t, slots.Start(ctx))
+
+	assert.Equal(t, 1, <-started)
+	assert.Equal(t, 1, slots.Slot())
+	assert.Equal(t, int64(2), slots.GetTerm())
+
+	// The free slot hasn't been acquired meanwhile.
+	_, err = kubeClient.CoordinationV1().Leases("test").Get(ctx, "test-0", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func heldLease(name, holder string, renewTime time.Time, term int32) *coordinationv1.Lease {
+	leaseDurationSeconds := int32(1)
+
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test",
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       strPtr(holder),
+			LeaseDurationSeconds: &leaseDurationSeconds,
+			AcquireTime:          &metav1.MicroTime{Time: renewTime},
+			RenewTime:            &metav1.MicroTime{Time: renewTime},
+			LeaseTransitions:     &term,
+		},
+	}
+}
@@ -0,0 +1,303 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/klog/v2"
+)
+
+const noSlot = -1
+
+var errSlotHeld = errors.New("member already holds another slot")
+
+// SlotGetter is implemented by the status of a multi slot election.
+type SlotGetter interface {
+	// Slot returns the index of the slot held by the member, -1 if it holds none.
+	Slot() int
+	// SlotHolders returns the current holder of each slot, an empty string means the slot is free.
+	SlotHolders() []string
+}
+
+// SlotCallbacks are invoked when the member starts or stops holding a slot.
+type SlotCallbacks struct {
+	OnStartedLeading func(ctx context.Context, slot int)
+	OnStoppedLeading func(slot int)
+	// OnNewLeader is optional, it is called when the holder of a slot changes.
+	OnNewLeader func(slot int, identity string)
+}
+
+// Slots runs an election per slot, backed by one lease per slot named `<LeaseName>-<slot>`.
+// A member holds at most one slot at a time, allowing K members to lead a shard each.
+type Slots struct {
+	config    Config
+	k8sClient kubernetes.Interface
+	electors  []*Elector
+
+	heartbeat *heartbeat
+
+	// acquireMu serializes slot acquisitions, making sure that a member never holds two slots.
+	acquireMu sync.Mutex
+	held      atomic.Int32
+	// resumeSlot is the slot the member still held when it started, no other slot can be acquired
+	// until it is resumed or until resumeUntil, when the lease expired.
+	resumeSlot  int
+	resumeUntil time.Time
+
+	metrics *slotMetrics
+}
+
+func NewSlots(cfg Config, slots int, k8sClient kubernetes.Interface, callbacks SlotCallbacks, reg prometheus.Registerer) (*Slots, error) {
+	if slots < 1 {
+		return nil, fmt.Errorf("invalid slot count %d, must be at least 1", slots)
+	}
+
+	// The leader metrics are shared by all the slot electors.
+	leaderMetrics := newLeaderMetrics(reg)
+	leaderelection.SetProvider(metricsProvider(func() leaderelection.LeaderMetric {
+		return leaderMetrics
+	}))
+
+	s := Slots{
+		config:     cfg,
+		k8sClient:  k8sClient,
+		electors:   make([]*Elector, slots),
+		resumeSlot: noSlot,
+		metrics:    newSlotMetrics(reg),
+	}
+
+	s.setHeld(noSlot)
+
+	electorMetrics := newElectorMetrics(reg)
+
+	for slot := range slots {
+		slotCfg := cfg
+		slotCfg.LeaseName = slotLeaseName(cfg.LeaseName, slot)
+
+		slotCallbacks := leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				callbacks.OnStartedLeading(ctx, slot)
+			},
+			OnStoppedLeading: func() {
+				// OnStoppedLeading is also called when the elector never led.
+				if !s.release(slot) {
+					return
+				}
+
+				callbacks.OnStoppedLeading(slot)
+			},
+		}
+
+		if callbacks.OnNewLeader != nil {
+			slotCallbacks.OnNewLeader = func(identity string) {
+				callbacks.OnNewLeader(slot, identity)
+			}
+		}
+
+		elector, err := newElector(
+			slotCfg,
+			k8sClient,
+			slotCallbacks,
+			electorMetrics,
+			func(_ *coordinationv1.Lease, write func() error) error {
+				return s.acquire(slot, write)
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		s.electors[slot] = elector
+	}
+
+	s.heartbeat = newHeartbeat(cfg, k8sClient, s.memberState)
+
+	// The slot electors gate their acquisitions on the configuration reported by the heartbeat of the member.
+	for _, elector := range s.electors {
+		elector.heartbeat = s.heartbeat
+	}
+
+	return &s, nil
+}
+
+func (s *Slots) Status() Status { return s }
+
+func (s *Slots) IsLeader() bool { return s.Slot() != noSlot }
+
+// GetLeader returns the holder of the first slot.
+func (s *Slots) GetLeader() string { return s.electors[0].GetLeader() }
+
+// IsCordoned always returns false, cordoning is not supported by the multi slot election.
+func (s *Slots) IsCordoned() bool { return false }
+
+func (s *Slots) GetZone() string { return s.electors[0].GetZone() }
+
+// GetLeaderZone returns the zone of the holder of the first slot.
+func (s *Slots) GetLeaderZone() string { return s.electors[0].GetLeaderZone() }
+
+// GetTerm returns the term of the held slot, or the term of the first slot if the member holds none.
+func (s *Slots) GetTerm() int64 {
+	slot := s.Slot()
+	if slot == noSlot {
+		slot = 0
+	}
+
+	return s.electors[slot].GetTerm()
+}
+
+func (s *Slots) Slot() int { return int(s.held.Load()) }
+
+func (s *Slots) SlotHolders() []string {
+	holders := make([]string, len(s.electors))
+
+	for slot, elector := range s.electors {
+		holders[slot] = elector.GetLeader()
+	}
+
+	return holders
+}
+
+// LeadingSince returns when the member started holding its current slot, zero if it holds none.
+func (s *Slots) LeadingSince() time.Time {
+	slot := s.Slot()
+	if slot == noSlot {
+		return time.Time{}
+	}
+
+	return s.electors[slot].LeadingSince()
+}
+
+// RunHeartbeat maintains the member heartbeat until the given context is done.
+func (s *Slots) RunHeartbeat(ctx context.Context) error { return s.heartbeat.run(ctx) }
+
+// SetHealthy reports the health of the local Prometheus in the member heartbeat.
+func (s *Slots) SetHealthy(healthy bool) { s.heartbeat.setHealthy(healthy) }
+
+func (s *Slots) Members(ctx context.Context) ([]Member, error) {
+	return listMembers(ctx, s.electors[0].leases(), s.config)
+}
+
+func (s *Slots) History() []HistoryEvent { return s.config.History.Events() }
+
+func (s *Slots) memberState() string {
+	if s.IsLeader() {
+		return MemberStateLeader
+	}
+
+	// All the slot electors are started and stopped together.
+	return s.electors[0].memberState()
+}
+
+// Start joins the election of every slot.
+// If the member still holds a slot from a previous run, this slot is resumed before any other can be acquired.
+func (s *Slots) Start(ctx context.Context) error {
+	s.reserveHeldSlot(ctx)
+
+	for _, elector := range s.electors {
+		if err := elector.Start(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop leaves the election of every slot.
+func (s *Slots) Stop(ctx context.Context) error {
+	stopped := false
+
+	for _, elector := range s.electors {
+		err := elector.Stop(ctx)
+		if errors.Is(err, ErrNotRunning) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		stopped = true
+	}
+
+	if !stopped {
+		return ErrNotRunning
+	}
+
+	return nil
+}
+
+// reserveHeldSlot looks up the slot the member still holds, and prevents acquiring another one until it is resumed.
+func (s *Slots) reserveHeldSlot(ctx context.Context) {
+	lookupCtx, cancel := context.WithTimeout(ctx, s.config.RenewDeadline)
+	defer cancel()
+
+	slot, _, held, err := LookupHeldSlot(lookupCtx, s.k8sClient, s.config, len(s.electors))
+	if err != nil {
+		klog.ErrorS(err, "Unable to check if the member still holds a slot")
+		return
+	}
+
+	if !held {
+		return
+	}
+
+	s.acquireMu.Lock()
+	defer s.acquireMu.Unlock()
+
+	s.resumeSlot = slot
+	s.resumeUntil = time.Now().Add(s.config.LeaseDuration)
+}
+
+// acquire writes the lease of the given slot only if the member doesn't hold or isn't resuming another slot.
+func (s *Slots) acquire(slot int, write func() error) error {
+	s.acquireMu.Lock()
+	defer s.acquireMu.Unlock()
+
+	if held := s.Slot(); held != noSlot && held != slot {
+		return errSlotHeld
+	}
+
+	if s.resumeSlot != noSlot && s.resumeSlot != slot && time.Now().Before(s.resumeUntil) {
+		return errSlotHeld
+	}
+
+	if err := write(); err != nil {
+		return err
+	}
+
+	s.setHeld(slot)
+	s.resumeSlot = noSlot
+
+	return nil
+}
+
+// release frees the given slot and tells if it was held.
+func (s *Slots) release(slot int) bool {
+	s.acquireMu.Lock()
+	defer s.acquireMu.Unlock()
+
+	if s.Slot() != slot {
+		return false
+	}
+
+	s.setHeld(noSlot)
+
+	return true
+}
+
+func (s *Slots) setHeld(slot int) {
+	s.held.Store(int32(slot))
+	s.metrics.setHeld(slot)
+}
+
+func slotLeaseName(leaseName string, slot int) string {
+	return fmt.Sprintf("%s-%d", leaseName, slot)
+}
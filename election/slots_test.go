@@ -0,0 +1,88 @@
+package election_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jlevesy/prometheus-elector/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSlots(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset()
+		members    = make(map[string]*election.Slots)
+	)
+
+	enforceLeaseResourceVersion(kubeClient)
+
+	for _, memberID := range []string{"foo", "bar", "biz"} {
+		slots, err := election.NewSlots(
+			newTestConfig(memberID),
+			2,
+			kubeClient,
+			election.SlotCallbacks{
+				OnStartedLeading: func(context.Context, int) {},
+				OnStoppedLeading: func(int) {},
+			},
+			nil,
+		)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			_ = slots.Stop(context.Background())
+		})
+
+		require.NoError(t, slots.Start(ctx))
+
+		members[memberID] = slots
+	}
+
+	// Both slots end up held, by two different members.
+	assertSlotsHeldByDistinctMembers := func() {
+		assert.Eventually(t, func() bool {
+			var (
+				holders = make(map[string]int)
+				leaders int
+			)
+
+			for memberID, slots := range members {
+				slot := slots.Slot()
+				if slot == -1 {
+					continue
+				}
+
+				leaders++
+				holders[slots.SlotHolders()[slot]]++
+
+				if slots.SlotHolders()[slot] != memberID {
+					return false
+				}
+			}
+
+			return leaders == 2 && len(holders) == 2
+		}, 10*time.Second, 100*time.Millisecond)
+	}
+
+	assertSlotsHeldByDistinctMembers()
+
+	// The follower takes over the slot of a leader leaving the election.
+	for memberID, slots := range members {
+		if !slots.IsLeader() {
+			continue
+		}
+
+		require.NoError(t, slots.Stop(ctx))
+		assert.False(t, slots.IsLeader())
+
+		delete(members, memberID)
+
+		break
+	}
+
+	assertSlotsHeldByDistinctMembers()
+}
@@ -0,0 +1,166 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	zoneLabel = "topology.kubernetes.io/zone"
+
+	// holderZoneAnnotation is the zone of the current holder of the lease.
+	holderZoneAnnotation = "prometheus-elector.io/holder-zone"
+	// zoneCandidateAnnotation designates a member of the preferred zone ready to take over the lease.
+	zoneCandidateAnnotation = "prometheus-elector.io/zone-candidate"
+	// zoneCandidateDeadlineAnnotation is the time after which the candidacy expires.
+	zoneCandidateDeadlineAnnotation = "prometheus-elector.io/zone-candidate-deadline"
+)
+
+var errOutOfZone = errors.New("member is outside of the preferred zone")
+
+// LookupZone returns the zone of the node running the given pod.
+func LookupZone(ctx context.Context, k8sClient kubernetes.Interface, namespace, podName string) (string, error) {
+	pod, err := k8sClient.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to get pod %s/%s: %w", namespace, podName, err)
+	}
+
+	if pod.Spec.NodeName == "" {
+		return "", fmt.Errorf("pod %s/%s is not scheduled", namespace, podName)
+	}
+
+	node, err := k8sClient.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to get node %s: %w", pod.Spec.NodeName, err)
+	}
+
+	zone, ok := node.Labels[zoneLabel]
+	if !ok {
+		return "", fmt.Errorf("node %s has no %s label", node.Name, zoneLabel)
+	}
+
+	return zone, nil
+}
+
+func (e *Elector) GetZone() string { return e.config.Zone }
+
+func (e *Elector) GetLeaderZone() string {
+	lease := e.lock.observedLease()
+	if lease == nil {
+		return ""
+	}
+
+	return lease.Annotations[holderZoneAnnotation]
+}
+
+func (e *Elector) outOfZone() bool {
+	return e.config.PreferredZone != "" && e.config.Zone != e.config.PreferredZone
+}
+
+// delayOutOfZoneAcquisition refuses to acquire the lease if the member is out of the preferred zone
+// and the lease became available less than OutOfZoneAcquireDelay ago, giving a chance to the members of the preferred zone.
+func (e *Elector) delayOutOfZoneAcquisition(lease *coordinationv1.Lease, now time.Time) error {
+	if !e.outOfZone() {
+		return nil
+	}
+
+	var availableSince time.Time
+
+	if joinedAt := e.joinedAt.Load(); joinedAt != nil {
+		availableSince = *joinedAt
+	}
+
+	// A lease that is not created yet carries our own record, it has never been held.
+	if lease.ResourceVersion != "" && lease.Spec.RenewTime != nil && lease.Spec.LeaseDurationSeconds != nil {
+		expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+		if expiry.After(availableSince) {
+			availableSince = expiry
+		}
+	}
+
+	if now.Before(availableSince.Add(e.config.OutOfZoneAcquireDelay)) {
+		return errOutOfZone
+	}
+
+	return nil
+}
+
+// observeZone hands over the lease to a member of the preferred zone when leading out of it,
+// or advertises the member as a candidate when it is in the preferred zone and the leader isn't.
+// It is called from the election loop, any call to the API server is made from another goroutine.
+func (e *Elector) observeZone(lease *coordinationv1.Lease) {
+	if e.config.PreferredZone == "" {
+		return
+	}
+
+	holder := lease.Spec.HolderIdentity
+	if holder == nil || *holder == "" {
+		return
+	}
+
+	now := time.Now()
+	candidate := lease.Annotations[zoneCandidateAnnotation]
+	hasCandidate := candidate != "" && beforeDeadline(lease, zoneCandidateDeadlineAnnotation, now)
+
+	if *holder == e.config.MemberID {
+		if !e.outOfZone() || !hasCandidate || candidate == e.config.MemberID {
+			return
+		}
+
+		if e.handingOver.CompareAndSwap(false, true) {
+			go e.handOver(candidate)
+		}
+
+		return
+	}
+
+	if e.outOfZone() || hasCandidate || lease.Annotations[holderZoneAnnotation] == e.config.PreferredZone {
+		return
+	}
+
+	if e.advertisingCandidacy.CompareAndSwap(false, true) {
+		go e.advertiseCandidacy()
+	}
+}
+
+func (e *Elector) handOver(candidate string) {
+	defer e.handingOver.Store(false)
+
+	klog.InfoS("Leading out of the preferred zone, handing over the leadership", "successor", candidate, "zone", e.config.PreferredZone)
+
+	annotations := e.successorAnnotations(candidate)
+	annotations[zoneCandidateAnnotation] = ""
+	annotations[zoneCandidateDeadlineAnnotation] = ""
+
+	err := e.stepDown(annotations, e.config.TransferTimeout, "transferred the leadership to "+candidate)
+	if err != nil && !errors.Is(err, ErrNotLeader) && !errors.Is(err, ErrNotRunning) {
+		klog.ErrorS(err, "Unable to hand over the leadership")
+	}
+}
+
+func (e *Elector) advertiseCandidacy() {
+	defer e.advertisingCandidacy.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.RenewDeadline)
+	defer cancel()
+
+	klog.InfoS("Leader is out of the preferred zone, advertising the member as a candidate", "zone", e.config.PreferredZone)
+
+	err := e.patchAnnotations(
+		ctx,
+		map[string]*string{
+			zoneCandidateAnnotation:         strPtr(e.config.MemberID),
+			zoneCandidateDeadlineAnnotation: strPtr(time.Now().Add(e.config.TransferTimeout).Format(time.RFC3339)),
+		},
+	)
+	if err != nil {
+		klog.ErrorS(err, "Unable to advertise the member as a candidate")
+	}
+}
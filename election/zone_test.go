@@ -0,0 +1,117 @@
+package election_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jlevesy/prometheus-elector/election"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLookupZone(t *testing.T) {
+	kubeClient := kubefake.NewClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "test"},
+			Spec:       corev1.PodSpec{NodeName: "node-a"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "test"},
+			Spec:       corev1.PodSpec{NodeName: "node-unlabeled"},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node-a",
+				Labels: map[string]string{"topology.kubernetes.io/zone": "zone-a"},
+			},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-unlabeled"},
+		},
+	)
+
+	zone, err := election.LookupZone(context.Background(), kubeClient, "test", "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "zone-a", zone)
+
+	_, err = election.LookupZone(context.Background(), kubeClient, "test", "bar")
+	assert.EqualError(t, err, "node node-unlabeled has no topology.kubernetes.io/zone label")
+
+	_, err = election.LookupZone(context.Background(), kubeClient, "test", "biz")
+	assert.Error(t, err)
+}
+
+func TestElector_PreferredZone(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset()
+	)
+
+	enforceLeaseResourceVersion(kubeClient)
+
+	fooConfig := newTestConfig("foo")
+	fooConfig.Zone = "zone-b"
+	fooConfig.PreferredZone = "zone-a"
+
+	barConfig := newTestConfig("bar")
+	barConfig.Zone = "zone-a"
+	barConfig.PreferredZone = "zone-a"
+
+	foo, fooStarted, _ := newTestElectorWithConfig(t, kubeClient, fooConfig)
+	bar, barStarted, _ := newTestElectorWithConfig(t, kubeClient, barConfig)
+
+	// foo is alone, it takes the lease once the out of zone delay elapsed.
+	require.NoError(t, foo.Start(ctx))
+	<-fooStarted
+
+	assert.Equal(t, "zone-b", foo.GetLeaderZone())
+
+	// bar joins, foo hands over the leadership because bar is in the preferred zone.
+	require.NoError(t, bar.Start(ctx))
+	<-barStarted
+
+	assert.Eventually(t, func() bool {
+		return !foo.IsLeader() && foo.GetLeader() == "bar" && foo.GetLeaderZone() == "zone-a"
+	}, 5*time.Second, 100*time.Millisecond)
+
+	assert.Equal(t, "zone-a", bar.GetZone())
+}
+
+func TestElector_OutOfZoneAcquireDelay(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset()
+	)
+
+	enforceLeaseResourceVersion(kubeClient)
+
+	fooConfig := newTestConfig("foo")
+	fooConfig.Zone = "zone-b"
+	fooConfig.PreferredZone = "zone-a"
+	fooConfig.OutOfZoneAcquireDelay = time.Hour
+
+	barConfig := newTestConfig("bar")
+	barConfig.Zone = "zone-a"
+	barConfig.PreferredZone = "zone-a"
+
+	foo, _, _ := newTestElectorWithConfig(t, kubeClient, fooConfig)
+	bar, barStarted, barStopped := newTestElectorWithConfig(t, kubeClient, barConfig)
+
+	require.NoError(t, foo.Start(ctx))
+	require.NoError(t, bar.Start(ctx))
+	<-barStarted
+
+	assert.True(t, bar.IsLeader())
+
+	// bar leaves, foo is still delaying its acquisition.
+	require.NoError(t, bar.Stop(ctx))
+	<-barStopped
+
+	time.Sleep(2 * time.Second)
+
+	assert.False(t, foo.IsLeader())
+}
@@ -0,0 +1,413 @@
+package endpoints
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	discoveryv1listers "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	managedBy = "prometheus-elector.io"
+
+	// termAnnotation is the term of the leadership that wrote the EndpointSlice.
+	termAnnotation = "prometheus-elector.io/term"
+	// memberAnnotation is the member that wrote the EndpointSlice.
+	memberAnnotation = "prometheus-elector.io/member"
+)
+
+type Config struct {
+	// ServiceName is the name of the selector-less service owning the EndpointSlice.
+	ServiceName string
+	Namespace   string
+	// PodName is the name of the pod of the member, its IP is published while leading.
+	PodName  string
+	PortName string
+	Port     int32
+	// ResyncPeriod is how often the cached EndpointSlice is checked while leading, retrying a failed publication.
+	// A slice modified from the outside or a new pod IP is repaired as soon as it is observed.
+	ResyncPeriod time.Duration
+}
+
+// writeResult tells what happened to the EndpointSlice when writing it.
+type writeResult int
+
+const (
+	// writeSkipped means that the EndpointSlice belongs to a more recent leadership, or to another member.
+	writeSkipped writeResult = iota
+	// writeUnchanged means that the EndpointSlice was already up to date.
+	writeUnchanged
+	writeDone
+)
+
+// Publisher maintains an EndpointSlice pointing at the leader, allowing a selector-less service to route to it.
+// The pod of the member and the EndpointSlice are watched once Run is called, so that the API server is only
+// written to when the EndpointSlice doesn't point at the member anymore.
+type Publisher struct {
+	config    Config
+	k8sClient kubernetes.Interface
+
+	podInformers   informers.SharedInformerFactory
+	pods           corev1listers.PodNamespaceLister
+	podsSynced     cache.InformerSynced
+	sliceInformers informers.SharedInformerFactory
+	slices         discoveryv1listers.EndpointSliceNamespaceLister
+	slicesSynced   cache.InformerSynced
+	// changed is notified when the pod or the EndpointSlice changed.
+	changed chan struct{}
+
+	mu sync.Mutex
+	// term of the leadership that published the EndpointSlice, -1 if not published.
+	term int64
+	// leadingTerm is the term of the current leadership of the member, -1 if not leading.
+	leadingTerm int64
+}
+
+func NewPublisher(cfg Config, k8sClient kubernetes.Interface) *Publisher {
+	podInformers := informers.NewSharedInformerFactoryWithOptions(
+		k8sClient,
+		0,
+		informers.WithNamespace(cfg.Namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", cfg.PodName).String()
+		}),
+	)
+
+	sliceInformers := informers.NewSharedInformerFactoryWithOptions(
+		k8sClient,
+		0,
+		informers.WithNamespace(cfg.Namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", sliceName(cfg)).String()
+		}),
+	)
+
+	podInformer := podInformers.Core().V1().Pods()
+	sliceInformer := sliceInformers.Discovery().V1().EndpointSlices()
+
+	return &Publisher{
+		config:         cfg,
+		k8sClient:      k8sClient,
+		podInformers:   podInformers,
+		pods:           podInformer.Lister().Pods(cfg.Namespace),
+		podsSynced:     podInformer.Informer().HasSynced,
+		sliceInformers: sliceInformers,
+		slices:         sliceInformer.Lister().EndpointSlices(cfg.Namespace),
+		slicesSynced:   sliceInformer.Informer().HasSynced,
+		changed:        make(chan struct{}, 1),
+		term:           -1,
+		leadingTerm:    -1,
+	}
+}
+
+// Run watches the pod and the EndpointSlice, and publishes the EndpointSlice again while the member leads
+// when it doesn't point at the member anymore, until the given context is done.
+func (p *Publisher) Run(ctx context.Context) error {
+	notify := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { p.notify() },
+		UpdateFunc: func(any, any) { p.notify() },
+		DeleteFunc: func(any) { p.notify() },
+	}
+
+	if _, err := p.podInformers.Core().V1().Pods().Informer().AddEventHandler(notify); err != nil {
+		return fmt.Errorf("unable to watch pod %s/%s: %w", p.config.Namespace, p.config.PodName, err)
+	}
+
+	if _, err := p.sliceInformers.Discovery().V1().EndpointSlices().Informer().AddEventHandler(notify); err != nil {
+		return fmt.Errorf("unable to watch EndpointSlice %s/%s: %w", p.config.Namespace, p.sliceName(), err)
+	}
+
+	p.podInformers.Start(ctx.Done())
+	p.sliceInformers.Start(ctx.Done())
+
+	defer p.podInformers.Shutdown()
+	defer p.sliceInformers.Shutdown()
+
+	ticker := time.NewTicker(p.config.ResyncPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			p.resync(ctx)
+		case <-p.changed:
+			p.resync(ctx)
+		}
+	}
+}
+
+func (p *Publisher) notify() {
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Publisher) resync(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.leadingTerm < 0 {
+		return
+	}
+
+	if err := p.publishLocked(ctx, p.leadingTerm); err != nil {
+		klog.ErrorS(err, "Failed to resync the leader endpoints", "term", p.leadingTerm)
+	}
+}
+
+// Publish points the EndpointSlice at the member for the leadership of the given term, until Clear is called.
+// It refuses to overwrite an EndpointSlice written by a more recent leadership.
+// If it fails, Run publishes it again.
+func (p *Publisher) Publish(ctx context.Context, term int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.leadingTerm = term
+
+	return p.publishLocked(ctx, term)
+}
+
+// publishLocked must be called with p.mu held.
+func (p *Publisher) publishLocked(ctx context.Context, term int64) error {
+	pod, err := p.getPod(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get pod %s/%s: %w", p.config.Namespace, p.config.PodName, err)
+	}
+
+	if pod.Status.PodIP == "" {
+		return fmt.Errorf("pod %s/%s has no IP", p.config.Namespace, p.config.PodName)
+	}
+
+	result, err := p.write(ctx, term, p.getCachedSlice, func(slice *discoveryv1.EndpointSlice) bool {
+		slice.AddressType = addressType(pod.Status.PodIP)
+		slice.Endpoints = []discoveryv1.Endpoint{
+			{
+				Addresses:  []string{pod.Status.PodIP},
+				Conditions: discoveryv1.EndpointConditions{Ready: boolPtr(true)},
+				NodeName:   strPtr(pod.Spec.NodeName),
+				TargetRef: &corev1.ObjectReference{
+					Kind:      "Pod",
+					Namespace: pod.Namespace,
+					Name:      pod.Name,
+					UID:       pod.UID,
+				},
+			},
+		}
+
+		return true
+	})
+	if err != nil || result == writeSkipped {
+		return err
+	}
+
+	p.term = term
+
+	if result == writeDone {
+		klog.InfoS("Published the leader endpoints", "service", p.config.ServiceName, "ip", pod.Status.PodIP, "term", term)
+	}
+
+	return nil
+}
+
+// Clear removes the member from the EndpointSlice, unless another leadership or another member published it since then.
+func (p *Publisher) Clear(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.leadingTerm = -1
+
+	if p.term < 0 {
+		return nil
+	}
+
+	// The cache might be stale or stopped when leaving the election, the EndpointSlice is read from the API server.
+	result, err := p.write(ctx, p.term, p.getSlice, func(slice *discoveryv1.EndpointSlice) bool {
+		if slice.Annotations[memberAnnotation] != p.config.PodName {
+			klog.InfoS("Leader endpoints have been published by another member, skipping", "member", slice.Annotations[memberAnnotation])
+			return false
+		}
+
+		slice.Endpoints = []discoveryv1.Endpoint{}
+
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if result == writeDone {
+		klog.InfoS("Cleared the leader endpoints", "service", p.config.ServiceName, "term", p.term)
+	}
+
+	p.term = -1
+
+	return nil
+}
+
+// write creates or updates the EndpointSlice returned by get, if it hasn't been written by a more recent leadership
+// and if mutate, which is given the slice before it takes the ownership of it, allows it.
+func (p *Publisher) write(
+	ctx context.Context,
+	term int64,
+	get func(ctx context.Context) (*discoveryv1.EndpointSlice, error),
+	mutate func(slice *discoveryv1.EndpointSlice) bool,
+) (writeResult, error) {
+	slices := p.k8sClient.DiscoveryV1().EndpointSlices(p.config.Namespace)
+
+	slice, err := get(ctx)
+	if apierrors.IsNotFound(err) {
+		slice, err = p.newSlice(ctx)
+		if err != nil {
+			return writeSkipped, err
+		}
+
+		if !mutate(slice) {
+			return writeSkipped, nil
+		}
+
+		p.setOwnership(slice, term)
+
+		if _, err = slices.Create(ctx, slice, metav1.CreateOptions{}); err != nil {
+			return writeSkipped, err
+		}
+
+		return writeDone, nil
+	}
+
+	if err != nil {
+		return writeSkipped, err
+	}
+
+	if sliceTerm, err := strconv.ParseInt(slice.Annotations[termAnnotation], 10, 64); err == nil && sliceTerm > term {
+		klog.InfoS("Leader endpoints have been published by a more recent leadership, skipping", "term", term, "publishedTerm", sliceTerm)
+		return writeSkipped, nil
+	}
+
+	original := slice.DeepCopy()
+
+	if !mutate(slice) {
+		return writeSkipped, nil
+	}
+
+	p.setOwnership(slice, term)
+
+	if apiequality.Semantic.DeepEqual(original, slice) {
+		return writeUnchanged, nil
+	}
+
+	if _, err = slices.Update(ctx, slice, metav1.UpdateOptions{}); err != nil {
+		return writeSkipped, err
+	}
+
+	return writeDone, nil
+}
+
+// getPod returns the pod of the member from the cache once it is synced, from the API server otherwise.
+func (p *Publisher) getPod(ctx context.Context) (*corev1.Pod, error) {
+	if p.podsSynced() {
+		return p.pods.Get(p.config.PodName)
+	}
+
+	return p.k8sClient.CoreV1().Pods(p.config.Namespace).Get(ctx, p.config.PodName, metav1.GetOptions{})
+}
+
+// getCachedSlice returns a copy of the EndpointSlice from the cache once it is synced, from the API server otherwise.
+// A stale copy fails to be updated, and the publication is retried once the cache caught up.
+func (p *Publisher) getCachedSlice(ctx context.Context) (*discoveryv1.EndpointSlice, error) {
+	if !p.slicesSynced() {
+		return p.getSlice(ctx)
+	}
+
+	slice, err := p.slices.Get(p.sliceName())
+	if err != nil {
+		return nil, err
+	}
+
+	return slice.DeepCopy(), nil
+}
+
+func (p *Publisher) getSlice(ctx context.Context) (*discoveryv1.EndpointSlice, error) {
+	return p.k8sClient.DiscoveryV1().EndpointSlices(p.config.Namespace).Get(ctx, p.sliceName(), metav1.GetOptions{})
+}
+
+func (p *Publisher) newSlice(ctx context.Context) (*discoveryv1.EndpointSlice, error) {
+	service, err := p.k8sClient.CoreV1().Services(p.config.Namespace).Get(ctx, p.config.ServiceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get service %s/%s: %w", p.config.Namespace, p.config.ServiceName, err)
+	}
+
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      p.sliceName(),
+			Namespace: p.config.Namespace,
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: p.config.ServiceName,
+				discoveryv1.LabelManagedBy:   managedBy,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "v1",
+					Kind:       "Service",
+					Name:       service.Name,
+					UID:        service.UID,
+				},
+			},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports: []discoveryv1.EndpointPort{
+			{
+				Name:     strPtr(p.config.PortName),
+				Port:     int32Ptr(p.config.Port),
+				Protocol: protocolPtr(corev1.ProtocolTCP),
+			},
+		},
+	}, nil
+}
+
+func (p *Publisher) setOwnership(slice *discoveryv1.EndpointSlice, term int64) {
+	if slice.Annotations == nil {
+		slice.Annotations = make(map[string]string, 2)
+	}
+
+	slice.Annotations[termAnnotation] = strconv.FormatInt(term, 10)
+	slice.Annotations[memberAnnotation] = p.config.PodName
+}
+
+func (p *Publisher) sliceName() string {
+	return sliceName(p.config)
+}
+
+func sliceName(cfg Config) string {
+	return cfg.ServiceName + "-leader"
+}
+
+func addressType(ip string) discoveryv1.AddressType {
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		return discoveryv1.AddressTypeIPv6
+	}
+
+	return discoveryv1.AddressTypeIPv4
+}
+
+func boolPtr(b bool) *bool                           { return &b }
+func strPtr(s string) *string                        { return &s }
+func int32Ptr(i int32) *int32                        { return &i }
+func protocolPtr(p corev1.Protocol) *corev1.Protocol { return &p }
@@ -0,0 +1,273 @@
+package endpoints_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/jlevesy/prometheus-elector/endpoints"
+)
+
+func TestPublisher(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset(
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "prometheus-leader", Namespace: "test", UID: types.UID("svc-uid")},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "test", UID: types.UID("foo-uid")},
+				Spec:       corev1.PodSpec{NodeName: "node-a"},
+				Status:     corev1.PodStatus{PodIP: "10.0.0.1"},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "test", UID: types.UID("bar-uid")},
+				Spec:       corev1.PodSpec{NodeName: "node-b"},
+				Status:     corev1.PodStatus{PodIP: "10.0.0.2"},
+			},
+		)
+		newPublisher = func(podName string) *endpoints.Publisher {
+			return endpoints.NewPublisher(
+				endpoints.Config{
+					ServiceName: "prometheus-leader",
+					Namespace:   "test",
+					PodName:     podName,
+					PortName:    "http",
+					Port:        9090,
+				},
+				kubeClient,
+			)
+		}
+		foo = newPublisher("foo")
+		bar = newPublisher("bar")
+
+		assertAddresses = func(t *testing.T, want ...string) *discoveryv1.EndpointSlice {
+			t.Helper()
+
+			slice, err := kubeClient.DiscoveryV1().EndpointSlices("test").Get(ctx, "prometheus-leader-leader", metav1.GetOptions{})
+			require.NoError(t, err)
+
+			var got []string
+			for _, endpoint := range slice.Endpoints {
+				got = append(got, endpoint.Addresses...)
+			}
+
+			assert.Equal(t, want, got)
+
+			return slice
+		}
+	)
+
+	require.NoError(t, foo.Publish(ctx, 1))
+
+	slice := assertAddresses(t, "10.0.0.1")
+	assert.Equal(t, "prometheus-leader", slice.Labels[discoveryv1.LabelServiceName])
+	assert.Equal(t, discoveryv1.AddressTypeIPv4, slice.AddressType)
+	require.Len(t, slice.OwnerReferences, 1)
+	assert.Equal(t, types.UID("svc-uid"), slice.OwnerReferences[0].UID)
+	require.Len(t, slice.Ports, 1)
+	assert.Equal(t, "http", *slice.Ports[0].Name)
+	assert.Equal(t, int32(9090), *slice.Ports[0].Port)
+	assert.Equal(t, types.UID("foo-uid"), slice.Endpoints[0].TargetRef.UID)
+
+	// A new leadership takes over the EndpointSlice.
+	require.NoError(t, bar.Publish(ctx, 2))
+	assertAddresses(t, "10.0.0.2")
+
+	// The previous leader can't clear or overwrite it anymore.
+	require.NoError(t, foo.Clear(ctx))
+	assertAddresses(t, "10.0.0.2")
+
+	require.NoError(t, foo.Publish(ctx, 1))
+	assertAddresses(t, "10.0.0.2")
+
+	require.NoError(t, bar.Clear(ctx))
+	assertAddresses(t)
+
+	// Clearing twice is a no-op.
+	require.NoError(t, bar.Clear(ctx))
+	assertAddresses(t)
+
+	// A member can't clear an EndpointSlice published by another member for the same term.
+	require.NoError(t, foo.Publish(ctx, 3))
+	require.NoError(t, bar.Publish(ctx, 3))
+	require.NoError(t, foo.Clear(ctx))
+	assertAddresses(t, "10.0.0.2")
+}
+
+func TestPublisher_Resync(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		kubeClient  = kubefake.NewClientset(
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "prometheus-leader", Namespace: "test"},
+			},
+			// The pod didn't get its IP yet.
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "test"},
+			},
+		)
+		publisher = endpoints.NewPublisher(
+			endpoints.Config{
+				ServiceName:  "prometheus-leader",
+				Namespace:    "test",
+				PodName:      "foo",
+				Port:         9090,
+				ResyncPeriod: 20 * time.Millisecond,
+			},
+			kubeClient,
+		)
+		publisherDone = make(chan error)
+
+		addresses = func() []string {
+			slice, err := kubeClient.DiscoveryV1().EndpointSlices("test").Get(ctx, "prometheus-leader-leader", metav1.GetOptions{})
+			if err != nil {
+				return nil
+			}
+
+			var got []string
+			for _, endpoint := range slice.Endpoints {
+				got = append(got, endpoint.Addresses...)
+			}
+
+			return got
+		}
+	)
+
+	defer cancel()
+
+	go func() { publisherDone <- publisher.Run(ctx) }()
+
+	require.Error(t, publisher.Publish(ctx, 1))
+
+	_, err := kubeClient.CoreV1().Pods("test").UpdateStatus(
+		ctx,
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "test"},
+			Status:     corev1.PodStatus{PodIP: "10.0.0.1"},
+		},
+		metav1.UpdateOptions{},
+	)
+	require.NoError(t, err)
+
+	// The failed publication is retried.
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"10.0.0.1"}, addresses())
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// An EndpointSlice modified from the outside is repaired while leading.
+	slice, err := kubeClient.DiscoveryV1().EndpointSlices("test").Get(ctx, "prometheus-leader-leader", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	slice.Endpoints = nil
+	_, err = kubeClient.DiscoveryV1().EndpointSlices("test").Update(ctx, slice, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"10.0.0.1"}, addresses())
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Once cleared, the EndpointSlice is not published anymore.
+	require.NoError(t, publisher.Clear(ctx))
+
+	assert.Never(t, func() bool {
+		return len(addresses()) > 0
+	}, 200*time.Millisecond, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-publisherDone)
+}
+
+func TestPublisher_ResyncFromCache(t *testing.T) {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		kubeClient  = kubefake.NewClientset(
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "prometheus-leader", Namespace: "test"},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "test"},
+				Status:     corev1.PodStatus{PodIP: "10.0.0.1"},
+			},
+		)
+		publisher = endpoints.NewPublisher(
+			endpoints.Config{
+				ServiceName:  "prometheus-leader",
+				Namespace:    "test",
+				PodName:      "foo",
+				Port:         9090,
+				ResyncPeriod: 20 * time.Millisecond,
+			},
+			kubeClient,
+		)
+		publisherDone = make(chan error)
+
+		// requests returns the verbs of the calls made to the API server, except the ones of the watches.
+		requests = func() []string {
+			var verbs []string
+			for _, action := range kubeClient.Actions() {
+				if verb := action.GetVerb(); verb != "list" && verb != "watch" {
+					verbs = append(verbs, verb+" "+action.GetResource().Resource)
+				}
+			}
+
+			return verbs
+		}
+	)
+
+	defer cancel()
+
+	go func() { publisherDone <- publisher.Run(ctx) }()
+
+	require.NoError(t, publisher.Publish(ctx, 1))
+
+	// Let the caches catch up with the publication.
+	time.Sleep(100 * time.Millisecond)
+	kubeClient.ClearActions()
+
+	// The EndpointSlice is up to date, the resyncs don't hit the API server.
+	time.Sleep(10 * 20 * time.Millisecond)
+	assert.Empty(t, requests())
+
+	// A modification from the outside is observed and repaired.
+	slice, err := kubeClient.DiscoveryV1().EndpointSlices("test").Get(ctx, "prometheus-leader-leader", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	slice.Endpoints = nil
+	_, err = kubeClient.DiscoveryV1().EndpointSlices("test").Update(ctx, slice, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		slice, err := kubeClient.DiscoveryV1().EndpointSlices("test").Get(ctx, "prometheus-leader-leader", metav1.GetOptions{})
+		return err == nil && len(slice.Endpoints) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-publisherDone)
+}
+
+func TestPublisher_MissingService(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset(
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "test"},
+				Status:     corev1.PodStatus{PodIP: "10.0.0.1"},
+			},
+		)
+		publisher = endpoints.NewPublisher(
+			endpoints.Config{ServiceName: "prometheus-leader", Namespace: "test", PodName: "foo", Port: 9090},
+			kubeClient,
+		)
+	)
+
+	require.Error(t, publisher.Publish(ctx, 1))
+}
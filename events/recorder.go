@@ -0,0 +1,117 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+const component = "prometheus-elector"
+
+// Reasons of the events emitted by prometheus-elector.
+const (
+	ReasonStartedLeading         = "StartedLeading"
+	ReasonStoppedLeading         = "StoppedLeading"
+	ReasonConfigReconciled       = "ConfigReconciled"
+	ReasonReloadFailed           = "ReloadFailed"
+	ReasonReloadRetried          = "ReloadRetried"
+	ReasonReloadRetriesExhausted = "ReloadRetriesExhausted"
+	ReasonHealthy                = "PrometheusHealthy"
+	ReasonUnhealthy              = "PrometheusUnhealthy"
+	ReasonSplitBrain             = "SplitBrain"
+	ReasonLeaderConfigFailed     = "LeaderConfigFailed"
+	ReasonLeaderUnfit            = "LeaderUnfit"
+)
+
+// Recorder records events about the member.
+type Recorder interface {
+	// Eventf records an event on the member pod.
+	Eventf(eventType, reason, messageFmt string, args ...any)
+	// ElectionEventf records an event on both the member pod and the election lease.
+	ElectionEventf(eventType, reason, messageFmt string, args ...any)
+}
+
+type NoopRecorder struct{}
+
+func (NoopRecorder) Eventf(string, string, string, ...any)         {}
+func (NoopRecorder) ElectionEventf(string, string, string, ...any) {}
+
+type Config struct {
+	Namespace string
+	PodName   string
+	LeaseName string
+	// Events are rate limited per involved object using a token bucket of Burst tokens,
+	// refilled by one token every RefillPeriod.
+	Burst        int
+	RefillPeriod time.Duration
+}
+
+// KubernetesRecorder records Kubernetes events.
+type KubernetesRecorder struct {
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+
+	pod   *corev1.ObjectReference
+	lease *corev1.ObjectReference
+}
+
+func NewKubernetes(ctx context.Context, k8sClient kubernetes.Interface, cfg Config) *KubernetesRecorder {
+	broadcaster := record.NewBroadcaster(
+		record.WithCorrelatorOptions(
+			record.CorrelatorOptions{
+				BurstSize: cfg.Burst,
+				QPS:       float32(1 / cfg.RefillPeriod.Seconds()),
+			},
+		),
+	)
+
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8sClient.CoreV1().Events(cfg.Namespace)})
+
+	pod := &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Namespace:  cfg.Namespace,
+		Name:       cfg.PodName,
+	}
+
+	// The UID allows tools like kubectl describe to find the events of the pod.
+	if p, err := k8sClient.CoreV1().Pods(cfg.Namespace).Get(ctx, cfg.PodName, metav1.GetOptions{}); err == nil {
+		pod.UID = p.UID
+	} else {
+		klog.ErrorS(err, "Unable to get the member pod, events will not reference its UID")
+	}
+
+	return &KubernetesRecorder{
+		broadcaster: broadcaster,
+		recorder:    broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component, Host: cfg.PodName}),
+		pod:         pod,
+		lease: &corev1.ObjectReference{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Namespace:  cfg.Namespace,
+			Name:       cfg.LeaseName,
+		},
+	}
+}
+
+func (r *KubernetesRecorder) Eventf(eventType, reason, messageFmt string, args ...any) {
+	r.recorder.Eventf(r.pod, eventType, reason, messageFmt, args...)
+}
+
+func (r *KubernetesRecorder) ElectionEventf(eventType, reason, messageFmt string, args ...any) {
+	r.recorder.Eventf(r.pod, eventType, reason, messageFmt, args...)
+	r.recorder.Eventf(r.lease, eventType, reason, "%s: %s", r.pod.Name, fmt.Sprintf(messageFmt, args...))
+}
+
+// Shutdown stops the recorder.
+func (r *KubernetesRecorder) Shutdown() {
+	r.broadcaster.Shutdown()
+}
@@ -0,0 +1,80 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/jlevesy/prometheus-elector/events"
+)
+
+func TestKubernetesRecorder(t *testing.T) {
+	var (
+		ctx        = context.Background()
+		kubeClient = kubefake.NewClientset(
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "test", UID: types.UID("foo-uid")},
+			},
+		)
+		recorder = events.NewKubernetes(
+			ctx,
+			kubeClient,
+			events.Config{
+				Namespace:    "test",
+				PodName:      "foo",
+				LeaseName:    "lease",
+				Burst:        25,
+				RefillPeriod: time.Minute,
+			},
+		)
+	)
+
+	defer recorder.Shutdown()
+
+	recorder.ElectionEventf(corev1.EventTypeNormal, events.ReasonStartedLeading, "Started leading, term %d", 2)
+	recorder.Eventf(corev1.EventTypeWarning, events.ReasonReloadFailed, "Failed to reload Prometheus: %v", "boom")
+
+	var gotEvents []corev1.Event
+
+	require.Eventually(t, func() bool {
+		eventList, err := kubeClient.CoreV1().Events("test").List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+
+		gotEvents = eventList.Items
+
+		return len(gotEvents) == 3
+	}, 5*time.Second, 50*time.Millisecond)
+
+	type event struct {
+		kind, uid, eventType, reason, message string
+	}
+
+	var got []event
+
+	for _, evt := range gotEvents {
+		got = append(got, event{
+			kind:      evt.InvolvedObject.Kind,
+			uid:       string(evt.InvolvedObject.UID),
+			eventType: evt.Type,
+			reason:    evt.Reason,
+			message:   evt.Message,
+		})
+	}
+
+	assert.ElementsMatch(
+		t,
+		[]event{
+			{kind: "Pod", uid: "foo-uid", eventType: "Normal", reason: "StartedLeading", message: "Started leading, term 2"},
+			{kind: "Lease", eventType: "Normal", reason: "StartedLeading", message: "foo: Started leading, term 2"},
+			{kind: "Pod", uid: "foo-uid", eventType: "Warning", reason: "ReloadFailed", message: "Failed to reload Prometheus: boom"},
+		},
+		got,
+	)
+}
@@ -0,0 +1,303 @@
+package fitness
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/jlevesy/prometheus-elector/election"
+	"github.com/jlevesy/prometheus-elector/events"
+)
+
+var errNoSample = errors.New("query returned no sample")
+
+type Config struct {
+	// PrometheusURL is the base URL of the local Prometheus, the queries are evaluated with its HTTP API.
+	PrometheusURL string
+	// Queries are PromQL expressions evaluating to a score between 0 and 1, the fitness is the lowest of them.
+	Queries []string
+	Period  time.Duration
+	Timeout time.Duration
+	// Threshold is the fitness below which a leader hands over the leadership to a fit peer, 0 disables it.
+	Threshold float64
+}
+
+// Scorer periodically computes the fitness of the member from the metrics of its local Prometheus,
+// and makes an unfit leader hand over the leadership to the fittest peer.
+type Scorer struct {
+	config   Config
+	memberID string
+	status   election.Status
+	members  election.MemberLister
+	transfer func(ctx context.Context, to string) error
+	recorder events.Recorder
+
+	httpClient *http.Client
+
+	mu     sync.RWMutex
+	score  float64
+	scored bool
+
+	scoreGauge prometheus.Gauge
+	handovers  prometheus.Counter
+}
+
+func NewScorer(
+	cfg Config,
+	memberID string,
+	status election.Status,
+	members election.MemberLister,
+	transfer func(ctx context.Context, to string) error,
+	recorder events.Recorder,
+	reg prometheus.Registerer,
+) *Scorer {
+	return &Scorer{
+		config:     cfg,
+		memberID:   memberID,
+		status:     status,
+		members:    members,
+		transfer:   transfer,
+		recorder:   recorder,
+		httpClient: http.DefaultClient,
+		scoreGauge: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "prometheus_elector",
+				Name:      "fitness_score",
+				Help:      "The fitness of the member computed from the metrics of its local Prometheus, between 0 and 1",
+			},
+		),
+		handovers: promauto.With(reg).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "prometheus_elector",
+				Name:      "fitness_handovers_total",
+				Help:      "The total amount of times the member handed over the leadership because it was unfit",
+			},
+		),
+	}
+}
+
+// Score returns the last fitness computed, false if none has been computed yet.
+func (s *Scorer) Score() (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.score, s.scored
+}
+
+// Run computes the fitness every period until the given context is done.
+func (s *Scorer) Run(ctx context.Context) error {
+	klog.InfoS("Starting fitness scoring", "period", s.config.Period, "threshold", s.config.Threshold)
+
+	ticker := time.NewTicker(s.config.Period)
+	defer ticker.Stop()
+
+	for {
+		s.evaluate(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scorer) evaluate(ctx context.Context) {
+	score := 1.0
+
+	for _, query := range s.config.Queries {
+		value, err := s.query(ctx, query)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A member that can't tell its fitness is not fit.
+		if err != nil {
+			klog.ErrorS(err, "Unable to evaluate the fitness query, considering the member unfit", "query", query)
+			value = 0
+		}
+
+		score = math.Min(score, value)
+	}
+
+	s.mu.Lock()
+	s.score, s.scored = score, true
+	s.mu.Unlock()
+
+	s.scoreGauge.Set(score)
+
+	if s.config.Threshold > 0 && score < s.config.Threshold && s.status.IsLeader() {
+		s.handOver(ctx, score)
+	}
+}
+
+// handOver transfers the leadership to the fittest peer, if any is fit enough.
+func (s *Scorer) handOver(ctx context.Context, score float64) {
+	members, err := s.members.Members(ctx)
+	if err != nil {
+		klog.ErrorS(err, "Unable to list the members, keeping the leadership")
+		return
+	}
+
+	successor, ok := fittestPeer(members, s.memberID, s.config.Threshold)
+	if !ok {
+		klog.InfoS("Leader is unfit but no peer is fit enough, keeping the leadership", "score", score)
+		return
+	}
+
+	klog.InfoS("Leader is unfit, handing over the leadership", "score", score, "successor", successor.ID, "successorScore", *successor.Fitness)
+	s.recorder.ElectionEventf(
+		corev1.EventTypeWarning,
+		events.ReasonLeaderUnfit,
+		"Leader fitness %.2f is below %.2f, handing over the leadership to %s",
+		score,
+		s.config.Threshold,
+		successor.ID,
+	)
+	s.handovers.Inc()
+
+	if err := s.transfer(ctx, successor.ID); err != nil {
+		klog.ErrorS(err, "Unable to hand over the leadership")
+	}
+}
+
+// fittestPeer returns the live and healthy follower with the highest fitness, which has to be above the threshold.
+// Ties are broken by member ID.
+func fittestPeer(members []election.Member, memberID string, threshold float64) (election.Member, bool) {
+	var candidates []election.Member
+
+	for _, member := range members {
+		if member.ID == memberID ||
+			member.Stale ||
+			member.State != election.MemberStateFollower ||
+			member.Health == election.MemberHealthUnhealthy ||
+			member.Fitness == nil ||
+			*member.Fitness < threshold {
+			continue
+		}
+
+		candidates = append(candidates, member)
+	}
+
+	if len(candidates) == 0 {
+		return election.Member{}, false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if *candidates[i].Fitness != *candidates[j].Fitness {
+			return *candidates[i].Fitness > *candidates[j].Fitness
+		}
+
+		return candidates[i].ID < candidates[j].ID
+	})
+
+	return candidates[0], true
+}
+
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type vectorSample struct {
+	Value [2]any `json:"value"`
+}
+
+// query evaluates the given expression on the local Prometheus and returns its value, clamped between 0 and 1.
+// A vector evaluates to its lowest sample.
+func (s *Scorer) query(ctx context.Context, query string) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		s.config.PrometheusURL+"/api/v1/query?"+url.Values{"query": []string{query}}.Encode(),
+		http.NoBody,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	var body queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("unable to decode the query response, status code %d: %w", resp.StatusCode, err)
+	}
+
+	if body.Status != "success" {
+		return 0, fmt.Errorf("query failed: %s", body.Error)
+	}
+
+	var values [][2]any
+
+	switch body.Data.ResultType {
+	case "scalar":
+		var value [2]any
+		if err := json.Unmarshal(body.Data.Result, &value); err != nil {
+			return 0, err
+		}
+
+		values = append(values, value)
+	case "vector":
+		var samples []vectorSample
+		if err := json.Unmarshal(body.Data.Result, &samples); err != nil {
+			return 0, err
+		}
+
+		for _, sample := range samples {
+			values = append(values, sample.Value)
+		}
+	default:
+		return 0, fmt.Errorf("unsupported result type %q, should be scalar or vector", body.Data.ResultType)
+	}
+
+	if len(values) == 0 {
+		return 0, errNoSample
+	}
+
+	score := 1.0
+
+	for _, value := range values {
+		raw, ok := value[1].(string)
+		if !ok {
+			return 0, fmt.Errorf("unexpected sample value %v", value[1])
+		}
+
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		if math.IsNaN(parsed) {
+			parsed = 0
+		}
+
+		score = math.Min(score, parsed)
+	}
+
+	return math.Max(score, 0), nil
+}
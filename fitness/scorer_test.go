@@ -0,0 +1,209 @@
+package fitness_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlevesy/prometheus-elector/election"
+	"github.com/jlevesy/prometheus-elector/events"
+	"github.com/jlevesy/prometheus-elector/fitness"
+)
+
+func TestScorer(t *testing.T) {
+	for _, testCase := range []struct {
+		desc          string
+		results       map[string]string
+		isLeader      bool
+		members       []election.Member
+		wantScore     float64
+		wantSuccessor string
+	}{
+		{
+			desc: "takes the lowest score of the queries",
+			results: map[string]string{
+				"scrape_health":     `{"resultType":"scalar","result":[1700000000,"0.8"]}`,
+				"remote_write_lag":  `{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"0.9"]},{"metric":{},"value":[1700000000,"0.6"]}]}`,
+				"rule_eval_success": `{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"1"]}]}`,
+			},
+			isLeader:  false,
+			wantScore: 0.6,
+		},
+		{
+			desc: "considers a query without sample as failing",
+			results: map[string]string{
+				"scrape_health":     `{"resultType":"scalar","result":[1700000000,"0.8"]}`,
+				"remote_write_lag":  `{"resultType":"vector","result":[]}`,
+				"rule_eval_success": `{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"1"]}]}`,
+			},
+			isLeader:  false,
+			wantScore: 0,
+		},
+		{
+			desc: "hands over to the fittest follower when the leader is unfit",
+			results: map[string]string{
+				"scrape_health":     `{"resultType":"scalar","result":[1700000000,"0.2"]}`,
+				"remote_write_lag":  `{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"1"]}]}`,
+				"rule_eval_success": `{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"NaN"]}]}`,
+			},
+			isLeader: true,
+			members: []election.Member{
+				{ID: "foo", State: election.MemberStateLeader, Fitness: floatPtr(0)},
+				{ID: "bar", State: election.MemberStateFollower, Fitness: floatPtr(0.7)},
+				{ID: "biz", State: election.MemberStateFollower, Fitness: floatPtr(0.9)},
+				{ID: "buz", State: election.MemberStateFollower, Fitness: floatPtr(1), Stale: true},
+				{ID: "boz", State: election.MemberStateFollower, Fitness: floatPtr(1), Health: election.MemberHealthUnhealthy},
+			},
+			wantScore:     0,
+			wantSuccessor: "biz",
+		},
+		{
+			desc: "keeps the leadership when no peer is fit",
+			results: map[string]string{
+				"scrape_health":     `{"resultType":"scalar","result":[1700000000,"0.2"]}`,
+				"remote_write_lag":  `{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"1"]}]}`,
+				"rule_eval_success": `{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"1"]}]}`,
+			},
+			isLeader: true,
+			members: []election.Member{
+				{ID: "foo", State: election.MemberStateLeader, Fitness: floatPtr(0.2)},
+				{ID: "bar", State: election.MemberStateFollower, Fitness: floatPtr(0.3)},
+				{ID: "biz", State: election.MemberStateFollower},
+			},
+			wantScore: 0.2,
+		},
+	} {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var (
+				ctx, cancel = context.WithCancel(context.Background())
+				reg         = prometheus.NewRegistry()
+				mu          sync.Mutex
+				transferred []string
+				server      = newPrometheus(t, testCase.results)
+				scorer      = fitness.NewScorer(
+					fitness.Config{
+						PrometheusURL: server.URL,
+						Queries:       []string{"scrape_health", "remote_write_lag", "rule_eval_success"},
+						Period:        time.Hour,
+						Timeout:       time.Second,
+						Threshold:     0.5,
+					},
+					"foo",
+					&statusStub{isLeader: testCase.isLeader},
+					&memberListerStub{members: testCase.members},
+					func(_ context.Context, to string) error {
+						mu.Lock()
+						defer mu.Unlock()
+
+						transferred = append(transferred, to)
+						return nil
+					},
+					events.NoopRecorder{},
+					reg,
+				)
+				scorerDone = make(chan struct{})
+			)
+
+			defer cancel()
+
+			_, scored := scorer.Score()
+			assert.False(t, scored)
+
+			go func() {
+				defer close(scorerDone)
+
+				assert.NoError(t, scorer.Run(ctx))
+			}()
+
+			require.Eventually(t, func() bool {
+				_, scored := scorer.Score()
+				return scored
+			}, 5*time.Second, 10*time.Millisecond)
+
+			cancel()
+			<-scorerDone
+
+			score, _ := scorer.Score()
+			assert.InDelta(t, testCase.wantScore, score, 0.001)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			var (
+				wantTransferred []string
+				wantHandovers   int
+			)
+
+			if testCase.wantSuccessor != "" {
+				wantTransferred = []string{testCase.wantSuccessor}
+				wantHandovers = 1
+			}
+
+			assert.Equal(t, wantTransferred, transferred)
+
+			err := testutil.GatherAndCompare(
+				reg,
+				strings.NewReader(fmt.Sprintf(`
+# HELP prometheus_elector_fitness_handovers_total The total amount of times the member handed over the leadership because it was unfit
+# TYPE prometheus_elector_fitness_handovers_total counter
+prometheus_elector_fitness_handovers_total %d
+`, wantHandovers)),
+				"prometheus_elector_fitness_handovers_total",
+			)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func newPrometheus(t *testing.T, results map[string]string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(rw, r)
+			return
+		}
+
+		result, ok := results[r.URL.Query().Get("query")]
+		if !ok {
+			rw.WriteHeader(http.StatusBadRequest)
+			_, _ = rw.Write([]byte(`{"status":"error","errorType":"bad_data","error":"unknown query"}`))
+			return
+		}
+
+		_, _ = fmt.Fprintf(rw, `{"status":"success","data":%s}`, result)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
+type statusStub struct {
+	isLeader bool
+}
+
+func (s *statusStub) IsLeader() bool        { return s.isLeader }
+func (s *statusStub) GetLeader() string     { return "foo" }
+func (s *statusStub) IsCordoned() bool      { return false }
+func (s *statusStub) GetZone() string       { return "" }
+func (s *statusStub) GetLeaderZone() string { return "" }
+func (s *statusStub) GetTerm() int64        { return 1 }
+
+type memberListerStub struct {
+	members []election.Member
+}
+
+func (s *memberListerStub) Members(context.Context) ([]election.Member, error) { return s.members, nil }
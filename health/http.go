@@ -6,7 +6,10 @@ import (
 	"net/http"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
+
+	"github.com/jlevesy/prometheus-elector/events"
 )
 
 type HTTPCheckConfig struct {
@@ -20,13 +23,15 @@ type HTTPCheckConfig struct {
 type HTTPChecker struct {
 	config    HTTPCheckConfig
 	callbacks Callbacks
+	recorder  events.Recorder
 
 	httpClient *http.Client
 }
 
-func NewHTTPChecker(cfg HTTPCheckConfig, cbs Callbacks) *HTTPChecker {
+func NewHTTPChecker(cfg HTTPCheckConfig, cbs Callbacks, recorder events.Recorder) *HTTPChecker {
 	return &HTTPChecker{
 		callbacks:  cbs,
+		recorder:   recorder,
 		config:     cfg,
 		httpClient: http.DefaultClient,
 	}
@@ -61,12 +66,16 @@ func (c *HTTPChecker) Check(ctx context.Context) error {
 			}
 
 			if status.successCount == c.config.SuccessThreshold {
+				c.recorder.Eventf(corev1.EventTypeNormal, events.ReasonHealthy, "Prometheus is healthy, joining the election")
+
 				if err := c.callbacks.OnHealthy(); err != nil {
 					klog.ErrorS(err, "Unable to notify healthiness")
 				}
 			}
 
 			if status.failureCount == c.config.FailureThreshold {
+				c.recorder.Eventf(corev1.EventTypeWarning, events.ReasonUnhealthy, "Prometheus is unhealthy, leaving the election")
+
 				if err := c.callbacks.OnUnHealthy(); err != nil {
 					klog.ErrorS(err, "Unable to notify unhealthiness")
 				}
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jlevesy/prometheus-elector/events"
 	"github.com/jlevesy/prometheus-elector/health"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -105,7 +106,7 @@ func TestHTTPChecker(t *testing.T) {
 				}
 
 				checkDone = make(chan struct{})
-				checker   = health.NewHTTPChecker(config, callbacks)
+				checker   = health.NewHTTPChecker(config, callbacks, events.NoopRecorder{})
 			)
 
 			ctx, cancel := context.WithCancel(context.Background())
@@ -0,0 +1,53 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type execHook struct {
+	command []string
+	timeout time.Duration
+}
+
+// NewExec returns a hook running the given command, the event is passed through the environment:
+// PROMETHEUS_ELECTOR_ROLE, PROMETHEUS_ELECTOR_TERM, PROMETHEUS_ELECTOR_LEADER and PROMETHEUS_ELECTOR_MEMBER_ID.
+func NewExec(command []string, timeout time.Duration) (Hook, error) {
+	if len(command) == 0 {
+		return nil, errors.New("empty exec hook command")
+	}
+
+	return &execHook{
+		command: command,
+		timeout: timeout,
+	}, nil
+}
+
+func (h *execHook) Name() string { return "exec:" + h.command[0] }
+
+func (h *execHook) Run(ctx context.Context, evt Event) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.command[0], h.command[1:]...)
+	cmd.Env = append(
+		os.Environ(),
+		"PROMETHEUS_ELECTOR_ROLE="+evt.Role,
+		"PROMETHEUS_ELECTOR_TERM="+strconv.FormatInt(evt.Term, 10),
+		"PROMETHEUS_ELECTOR_LEADER="+evt.Leader,
+		"PROMETHEUS_ELECTOR_MEMBER_ID="+evt.MemberID,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("command %q failed: %w, output: %s", strings.Join(h.command, " "), err, output)
+	}
+
+	return nil
+}
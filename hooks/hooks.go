@@ -0,0 +1,110 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"k8s.io/klog/v2"
+)
+
+// Event describes a role change of the member.
+type Event struct {
+	Role     string `json:"role"`
+	Term     int64  `json:"term"`
+	Leader   string `json:"leader"`
+	MemberID string `json:"member_id"`
+}
+
+// Hook is a side effect triggered on every role change.
+type Hook interface {
+	// Name identifies the hook in logs and metrics.
+	Name() string
+	Run(ctx context.Context, evt Event) error
+}
+
+// Runner runs hooks one after the other, retrying each of them until it succeeds or runs out of attempts.
+type Runner struct {
+	hooks       []Hook
+	maxAttempts int
+	delay       time.Duration
+
+	runs     *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func NewRunner(hooks []Hook, maxAttempts int, delay time.Duration, reg prometheus.Registerer) *Runner {
+	return &Runner{
+		hooks:       hooks,
+		maxAttempts: maxAttempts,
+		delay:       delay,
+		runs: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "prometheus_elector",
+				Name:      "hook_runs_total",
+				Help:      "The total amount of times a transition hook has been run, by outcome",
+			},
+			[]string{"hook", "outcome"},
+		),
+		duration: promauto.With(reg).NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: "prometheus_elector",
+				Name:      "hook_run_duration_seconds",
+				Help:      "The time it took to run a transition hook",
+			},
+			[]string{"hook"},
+		),
+	}
+}
+
+// Run runs all the hooks for the given event, failures are logged.
+func (r *Runner) Run(ctx context.Context, evt Event) {
+	for _, hook := range r.hooks {
+		if err := r.runWithRetry(ctx, hook, evt); err != nil {
+			klog.ErrorS(err, "Transition hook failed", "hook", hook.Name(), "role", evt.Role, "term", evt.Term)
+		}
+	}
+}
+
+func (r *Runner) runWithRetry(ctx context.Context, hook Hook, evt Event) error {
+	var err error
+
+	for attempt := 1; attempt <= r.maxAttempts; attempt++ {
+		if err = r.run(ctx, hook, evt); err == nil {
+			return nil
+		}
+
+		if errors.Is(err, context.Canceled) || attempt == r.maxAttempts {
+			break
+		}
+
+		klog.ErrorS(err, "Transition hook failed, will retry...", "hook", hook.Name(), "attempt", attempt, "maxAttempts", r.maxAttempts)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.delay):
+		}
+	}
+
+	return err
+}
+
+func (r *Runner) run(ctx context.Context, hook Hook, evt Event) error {
+	startTime := time.Now()
+
+	err := hook.Run(ctx, evt)
+
+	r.duration.WithLabelValues(hook.Name()).Observe(time.Since(startTime).Seconds())
+
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+
+	r.runs.WithLabelValues(hook.Name(), outcome).Inc()
+
+	return err
+}
@@ -0,0 +1,84 @@
+package hooks_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlevesy/prometheus-elector/hooks"
+)
+
+func TestRunner(t *testing.T) {
+	var (
+		ctx = context.Background()
+		reg = prometheus.NewRegistry()
+		evt = hooks.Event{Role: "leader", Term: 3, Leader: "pod-0", MemberID: "pod-0"}
+
+		received []hooks.Event
+		srv      = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+			var got hooks.Event
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+
+			received = append(received, got)
+
+			if len(received) < 2 {
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+
+		outputPath = filepath.Join(t.TempDir(), "output")
+	)
+
+	defer srv.Close()
+
+	webhook, err := hooks.NewWebhook(srv.URL+"/hook?token=secret", time.Second)
+	require.NoError(t, err)
+
+	execHook, err := hooks.NewExec(
+		[]string{"/bin/sh", "-c", `echo "$PROMETHEUS_ELECTOR_ROLE $PROMETHEUS_ELECTOR_TERM $PROMETHEUS_ELECTOR_LEADER $PROMETHEUS_ELECTOR_MEMBER_ID" > ` + outputPath},
+		time.Second,
+	)
+	require.NoError(t, err)
+
+	failingHook, err := hooks.NewExec([]string{"/bin/false"}, time.Second)
+	require.NoError(t, err)
+
+	hooks.NewRunner([]hooks.Hook{webhook, execHook, failingHook}, 3, 0, reg).Run(ctx, evt)
+
+	assert.Equal(t, []hooks.Event{evt, evt}, received)
+
+	output, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, "leader 3 pod-0 pod-0\n", string(output))
+
+	wantMetrics := `
+# HELP prometheus_elector_hook_runs_total The total amount of times a transition hook has been run, by outcome
+# TYPE prometheus_elector_hook_runs_total counter
+prometheus_elector_hook_runs_total{hook="exec:/bin/false",outcome="failure"} 3
+prometheus_elector_hook_runs_total{hook="exec:/bin/sh",outcome="success"} 1
+prometheus_elector_hook_runs_total{hook="webhook:` + srv.Listener.Addr().String() + `/hook",outcome="failure"} 1
+prometheus_elector_hook_runs_total{hook="webhook:` + srv.Listener.Addr().String() + `/hook",outcome="success"} 1
+`
+
+	err = testutil.GatherAndCompare(reg, bytes.NewBuffer([]byte(wantMetrics)), "prometheus_elector_hook_runs_total")
+	require.NoError(t, err)
+}
+
+func TestNewWebhook_InvalidURL(t *testing.T) {
+	_, err := hooks.NewWebhook("ftp://example.com", time.Second)
+	assert.EqualError(t, err, `invalid webhook URL "ftp://example.com", scheme must be http or https`)
+}
@@ -0,0 +1,97 @@
+package hooks
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Queue runs the side effects of the role changes one after the other, off the election callbacks.
+// Each action gets its own timeout, so it still completes while the member shuts down.
+type Queue struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending []func(context.Context)
+	closed  bool
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+func NewQueue(timeout time.Duration) *Queue {
+	q := &Queue{
+		timeout: timeout,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	go q.run()
+
+	return q
+}
+
+// Submit queues an action without waiting for it, actions submitted after Close are dropped.
+func (q *Queue) Submit(action func(ctx context.Context)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+
+	q.pending = append(q.pending, action)
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Close runs the pending actions and waits for them to complete.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.wake)
+	}
+	q.mu.Unlock()
+
+	<-q.done
+}
+
+func (q *Queue) run() {
+	defer close(q.done)
+
+	for {
+		_, open := <-q.wake
+
+		for {
+			action, ok := q.next()
+			if !ok {
+				break
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), q.timeout)
+			action(ctx)
+			cancel()
+		}
+
+		if !open {
+			return
+		}
+	}
+}
+
+func (q *Queue) next() (func(context.Context), bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) == 0 {
+		return nil, false
+	}
+
+	action := q.pending[0]
+	q.pending = q.pending[1:]
+
+	return action, true
+}
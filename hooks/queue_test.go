@@ -0,0 +1,62 @@
+package hooks_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlevesy/prometheus-elector/hooks"
+)
+
+func TestQueue(t *testing.T) {
+	var (
+		queue   = hooks.NewQueue(time.Second)
+		release = make(chan struct{})
+
+		mu  sync.Mutex
+		ran []string
+	)
+
+	record := func(name string) func(context.Context) {
+		return func(ctx context.Context) {
+			if name == "leader" {
+				<-release
+			}
+
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			ran = append(ran, name)
+		}
+	}
+
+	// Submitting doesn't wait for the running action.
+	queue.Submit(record("leader"))
+	queue.Submit(record("follower"))
+	queue.Submit(record("leader again"))
+
+	close(release)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(ran) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	queue.Submit(record("stopped"))
+	queue.Close()
+
+	// Closed, the queue drops the actions.
+	queue.Submit(record("dropped"))
+	queue.Close()
+
+	assert.Equal(t, []string{"leader", "follower", "leader again", "stopped"}, ran)
+}
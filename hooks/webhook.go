@@ -0,0 +1,71 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type webhook struct {
+	name    string
+	url     string
+	timeout time.Duration
+
+	httpClient *http.Client
+}
+
+// NewWebhook returns a hook posting the event as JSON to the given URL.
+func NewWebhook(rawURL string, timeout time.Duration) (Hook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid webhook URL %q, scheme must be http or https", rawURL)
+	}
+
+	return &webhook{
+		// Leave out the query and the credentials, which might hold secrets.
+		name:       "webhook:" + u.Host + u.Path,
+		url:        rawURL,
+		timeout:    timeout,
+		httpClient: http.DefaultClient,
+	}, nil
+}
+
+func (h *webhook) Name() string { return h.name }
+
+func (h *webhook) Run(ctx context.Context, evt Event) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/jlevesy/prometheus-elector/events"
 	"github.com/jlevesy/prometheus-elector/notifier"
 )
 
@@ -20,6 +21,7 @@ func TestHTTPNotifierWithRetries(t *testing.T) {
 	var (
 		totalReceived int
 		reg           = prometheus.NewRegistry()
+		recorder      = &recorderStub{}
 		ctx           = context.Background()
 		srv           = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 			require.Equal(t, r.Method, http.MethodPost)
@@ -40,6 +42,7 @@ func TestHTTPNotifierWithRetries(t *testing.T) {
 			),
 			10,
 			0*time.Second,
+			recorder,
 		)
 	)
 
@@ -58,6 +61,17 @@ prometheus_elector_notifier_calls_total{} 5
 	require.NoError(t, err)
 
 	assert.Equal(t, 5, totalReceived)
+	assert.Equal(
+		t,
+		[]string{
+			events.ReasonReloadFailed,
+			events.ReasonReloadFailed,
+			events.ReasonReloadFailed,
+			events.ReasonReloadFailed,
+			events.ReasonReloadRetried,
+		},
+		recorder.reasons,
+	)
 	assert.NoError(t, testutil.GatherAndCompare(
 		reg,
 		bytes.NewBuffer([]byte(wantMetrics)),
@@ -70,6 +84,7 @@ func TestHTTPNotifierExhaustRetries(t *testing.T) {
 	var (
 		totalReceived int
 		ctx           = context.Background()
+		recorder      = &recorderStub{}
 		srv           = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 			require.Equal(t, r.Method, http.MethodPost)
 			totalReceived++
@@ -83,6 +98,7 @@ func TestHTTPNotifierExhaustRetries(t *testing.T) {
 			),
 			10,
 			0*time.Second,
+			recorder,
 		)
 	)
 
@@ -92,6 +108,8 @@ func TestHTTPNotifierExhaustRetries(t *testing.T) {
 	require.ErrorContains(t, err, "notifier exhausted all retries")
 
 	assert.Equal(t, 10, totalReceived)
+	assert.Len(t, recorder.reasons, 11)
+	assert.Equal(t, events.ReasonReloadRetriesExhausted, recorder.reasons[10])
 }
 
 func TestHTTPNotifierNoRetryOnContextCanceled(t *testing.T) {
@@ -111,6 +129,7 @@ func TestHTTPNotifierNoRetryOnContextCanceled(t *testing.T) {
 			),
 			10,
 			0*time.Second,
+			events.NoopRecorder{},
 		)
 	)
 
@@ -122,3 +141,43 @@ func TestHTTPNotifierNoRetryOnContextCanceled(t *testing.T) {
 
 	assert.Equal(t, 0, totalReceived)
 }
+
+type recorderStub struct {
+	reasons []string
+}
+
+func (r *recorderStub) Eventf(_, reason, _ string, _ ...any) {
+	r.reasons = append(r.reasons, reason)
+}
+
+func (r *recorderStub) ElectionEventf(_, reason, _ string, _ ...any) {
+	r.reasons = append(r.reasons, reason)
+}
+
+func TestHTTPNotifierNoRetryWhenCanceledDuringDelay(t *testing.T) {
+	var (
+		totalReceived int
+		ctx, cancel   = context.WithCancel(context.Background())
+		srv           = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			totalReceived++
+			rw.WriteHeader(http.StatusInternalServerError)
+			cancel()
+		}))
+		notifier = notifier.WithRetry(
+			notifier.NewHTTP(
+				srv.URL,
+				http.MethodPost,
+				time.Second,
+			),
+			10,
+			time.Minute,
+			events.NoopRecorder{},
+		)
+	)
+
+	defer srv.Close()
+
+	err := notifier.Notify(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, 1, totalReceived)
+}
@@ -6,20 +6,25 @@ import (
 	"fmt"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
+
+	"github.com/jlevesy/prometheus-elector/events"
 )
 
 type retryNotifier struct {
 	next        Notifier
 	delay       time.Duration
 	maxAttempts int
+	recorder    events.Recorder
 }
 
-func WithRetry(next Notifier, maxAttempts int, delay time.Duration) Notifier {
+func WithRetry(next Notifier, maxAttempts int, delay time.Duration, recorder events.Recorder) Notifier {
 	return &retryNotifier{
 		next:        next,
 		delay:       delay,
 		maxAttempts: maxAttempts,
+		recorder:    recorder,
 	}
 }
 
@@ -28,6 +33,10 @@ func (r *retryNotifier) Notify(ctx context.Context) error {
 
 	for j := r.maxAttempts; j > 0; j-- {
 		if err = r.next.Notify(ctx); err == nil {
+			if j < r.maxAttempts {
+				r.recorder.Eventf(corev1.EventTypeNormal, events.ReasonReloadRetried, "Reloaded Prometheus after %d attempts", r.maxAttempts-j+1)
+			}
+
 			return nil
 		}
 
@@ -35,11 +44,20 @@ func (r *retryNotifier) Notify(ctx context.Context) error {
 			return nil
 		}
 
+		r.recorder.Eventf(corev1.EventTypeWarning, events.ReasonReloadFailed, "Failed to reload Prometheus: %v", err)
+
 		if j > 0 {
 			klog.ErrorS(err, "Failed to notify prometheus, will retry...", "attempt", r.maxAttempts-j, "maxAttempts", r.maxAttempts)
-			time.Sleep(r.delay)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(r.delay):
+			}
 		}
 	}
 
+	r.recorder.Eventf(corev1.EventTypeWarning, events.ReasonReloadRetriesExhausted, "Failed to reload Prometheus after %d attempts: %v", r.maxAttempts, err)
+
 	return fmt.Errorf("notifier exhausted all retries: %w", err)
 }
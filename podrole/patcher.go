@@ -0,0 +1,179 @@
+package podrole
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// RoleLabel is set on the member pod to its current role.
+	RoleLabel = "prometheus-elector/role"
+	// LeaderCondition is the pod condition telling if the member leads, it can be used as a readiness gate.
+	LeaderCondition corev1.PodConditionType = "prometheus-elector.io/leader"
+
+	RoleLeader   = "leader"
+	RoleFollower = "follower"
+
+	reasonLeading   = "Leading"
+	reasonFollowing = "Following"
+	reasonShutdown  = "Shutdown"
+)
+
+type Config struct {
+	Namespace string
+	PodName   string
+}
+
+// Patcher reflects the role of the member on its own pod, with a label and a condition.
+type Patcher struct {
+	config    Config
+	k8sClient kubernetes.Interface
+}
+
+func NewPatcher(cfg Config, k8sClient kubernetes.Interface) *Patcher {
+	return &Patcher{
+		config:    cfg,
+		k8sClient: k8sClient,
+	}
+}
+
+// Preflight makes sure that the member is allowed to patch its pod and its status.
+func (p *Patcher) Preflight(ctx context.Context) error {
+	for _, attrs := range []authorizationv1.ResourceAttributes{
+		{Verb: "get", Resource: "pods"},
+		{Verb: "patch", Resource: "pods"},
+		{Verb: "patch", Resource: "pods", Subresource: "status"},
+	} {
+		attrs.Namespace = p.config.Namespace
+		attrs.Name = p.config.PodName
+
+		review, err := p.k8sClient.AuthorizationV1().SelfSubjectAccessReviews().Create(
+			ctx,
+			&authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attrs},
+			},
+			metav1.CreateOptions{},
+		)
+		if err != nil {
+			return fmt.Errorf("unable to review access to %s %s: %w", attrs.Verb, resourceName(attrs), err)
+		}
+
+		if !review.Status.Allowed {
+			return fmt.Errorf("not allowed to %s %s in namespace %s", attrs.Verb, resourceName(attrs), attrs.Namespace)
+		}
+	}
+
+	return nil
+}
+
+// SetRole sets the role label and the leader condition of the pod.
+func (p *Patcher) SetRole(ctx context.Context, leader bool) error {
+	var (
+		role   = RoleFollower
+		status = corev1.ConditionFalse
+		reason = reasonFollowing
+	)
+
+	if leader {
+		role = RoleLeader
+		status = corev1.ConditionTrue
+		reason = reasonLeading
+	}
+
+	if err := p.patchLabel(ctx, &role); err != nil {
+		return err
+	}
+
+	if err := p.patchCondition(ctx, status, reason); err != nil {
+		return err
+	}
+
+	klog.InfoS("Updated the pod role", "role", role)
+
+	return nil
+}
+
+// Revert removes the role label and sets the leader condition to false, it is meant to be called on shutdown.
+func (p *Patcher) Revert(ctx context.Context) error {
+	if err := p.patchLabel(ctx, nil); err != nil {
+		return err
+	}
+
+	return p.patchCondition(ctx, corev1.ConditionFalse, reasonShutdown)
+}
+
+// patchLabel sets the role label, or removes it if role is nil.
+func (p *Patcher) patchLabel(ctx context.Context, role *string) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"labels": map[string]*string{RoleLabel: role},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = p.k8sClient.CoreV1().Pods(p.config.Namespace).Patch(ctx, p.config.PodName, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to patch the labels of pod %s/%s: %w", p.config.Namespace, p.config.PodName, err)
+	}
+
+	return nil
+}
+
+// patchCondition sets the leader condition, its transition time only changes along with its status.
+func (p *Patcher) patchCondition(ctx context.Context, status corev1.ConditionStatus, reason string) error {
+	pod, err := p.k8sClient.CoreV1().Pods(p.config.Namespace).Get(ctx, p.config.PodName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get pod %s/%s: %w", p.config.Namespace, p.config.PodName, err)
+	}
+
+	lastTransitionTime := metav1.NewTime(time.Now())
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == LeaderCondition && condition.Status == status {
+			lastTransitionTime = condition.LastTransitionTime
+		}
+	}
+
+	// Pod conditions are merged by type.
+	patch, err := json.Marshal(map[string]any{
+		"status": map[string]any{
+			"conditions": []corev1.PodCondition{
+				{
+					Type:               LeaderCondition,
+					Status:             status,
+					Reason:             reason,
+					LastTransitionTime: lastTransitionTime,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = p.k8sClient.CoreV1().Pods(p.config.Namespace).Patch(ctx, p.config.PodName, types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return fmt.Errorf("unable to patch the status of pod %s/%s: %w", p.config.Namespace, p.config.PodName, err)
+	}
+
+	return nil
+}
+
+func resourceName(attrs authorizationv1.ResourceAttributes) string {
+	if attrs.Subresource == "" {
+		return attrs.Resource
+	}
+
+	return attrs.Resource + "/" + attrs.Subresource
+}
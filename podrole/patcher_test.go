@@ -0,0 +1,117 @@
+package podrole_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/jlevesy/prometheus-elector/podrole"
+)
+
+func TestPatcher_SetRole(t *testing.T) {
+	var (
+		ctx = context.Background()
+		// Set by a previous run of the member.
+		followingSince = metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+		kubeClient     = kubefake.NewClientset(
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "test", Labels: map[string]string{"app": "prometheus"}},
+				Status: corev1.PodStatus{
+					Conditions: []corev1.PodCondition{
+						{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+						{Type: podrole.LeaderCondition, Status: corev1.ConditionFalse, Reason: "Following", LastTransitionTime: followingSince},
+					},
+				},
+			},
+		)
+		patcher = podrole.NewPatcher(podrole.Config{Namespace: "test", PodName: "foo"}, kubeClient)
+
+		assertPod = func(t *testing.T, wantLabels map[string]string, wantStatus corev1.ConditionStatus, wantReason string) metav1.Time {
+			t.Helper()
+
+			pod, err := kubeClient.CoreV1().Pods("test").Get(ctx, "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+
+			assert.Equal(t, wantLabels, pod.Labels)
+
+			conditions := make(map[corev1.PodConditionType]corev1.PodCondition, len(pod.Status.Conditions))
+			for _, condition := range pod.Status.Conditions {
+				conditions[condition.Type] = condition
+			}
+
+			assert.Len(t, conditions, 2)
+			assert.Equal(t, corev1.ConditionTrue, conditions[corev1.PodReady].Status)
+			assert.Equal(t, wantStatus, conditions[podrole.LeaderCondition].Status)
+			assert.Equal(t, wantReason, conditions[podrole.LeaderCondition].Reason)
+
+			return conditions[podrole.LeaderCondition].LastTransitionTime
+		}
+	)
+
+	require.NoError(t, patcher.SetRole(ctx, false))
+	transitionTime := assertPod(t, map[string]string{"app": "prometheus", podrole.RoleLabel: podrole.RoleFollower}, corev1.ConditionFalse, "Following")
+	// The condition status didn't change.
+	assert.True(t, followingSince.Equal(&transitionTime))
+
+	require.NoError(t, patcher.SetRole(ctx, true))
+	transitionTime = assertPod(t, map[string]string{"app": "prometheus", podrole.RoleLabel: podrole.RoleLeader}, corev1.ConditionTrue, "Leading")
+	assert.True(t, transitionTime.After(followingSince.Time))
+
+	require.NoError(t, patcher.Revert(ctx))
+	assertPod(t, map[string]string{"app": "prometheus"}, corev1.ConditionFalse, "Shutdown")
+}
+
+func TestPatcher_Preflight(t *testing.T) {
+	for _, testCase := range []struct {
+		desc    string
+		allowed func(attrs *authorizationv1.ResourceAttributes) bool
+		wantErr string
+	}{
+		{
+			desc:    "allowed",
+			allowed: func(*authorizationv1.ResourceAttributes) bool { return true },
+		},
+		{
+			desc: "status patch denied",
+			allowed: func(attrs *authorizationv1.ResourceAttributes) bool {
+				return attrs.Subresource != "status"
+			},
+			wantErr: "not allowed to patch pods/status in namespace test",
+		},
+	} {
+		t.Run(testCase.desc, func(t *testing.T) {
+			kubeClient := kubefake.NewClientset()
+			kubeClient.PrependReactor(
+				"create",
+				"selfsubjectaccessreviews",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+
+					assert.Equal(t, "test", review.Spec.ResourceAttributes.Namespace)
+					assert.Equal(t, "foo", review.Spec.ResourceAttributes.Name)
+
+					review.Status.Allowed = testCase.allowed(review.Spec.ResourceAttributes)
+
+					return true, review, nil
+				},
+			)
+
+			err := podrole.NewPatcher(podrole.Config{Namespace: "test", PodName: "foo"}, kubeClient).Preflight(context.Background())
+			if testCase.wantErr != "" {
+				assert.EqualError(t, err, testCase.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
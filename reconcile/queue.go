@@ -0,0 +1,365 @@
+package reconcile
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/jlevesy/prometheus-elector/config"
+	"github.com/jlevesy/prometheus-elector/events"
+	"github.com/jlevesy/prometheus-elector/notifier"
+)
+
+const (
+	// driftReasonFile means that the output file doesn't match the configuration rendered for the current state.
+	driftReasonFile = "file"
+	// driftReasonNotify means that Prometheus couldn't be notified of the last configuration written.
+	driftReasonNotify = "notify"
+	// driftReasonReload means that Prometheus didn't load the last configuration written.
+	driftReasonReload = "reload"
+
+	// maxLeaderRetryDelay caps the backoff of the retries of a failed leader reconciliation.
+	maxLeaderRetryDelay = time.Minute
+)
+
+type Config struct {
+	// ResyncPeriod is how often the configuration is checked for drift, 0 disables it.
+	ResyncPeriod time.Duration
+	// ReloadChecker tells if Prometheus loaded the last configuration written, optional.
+	ReloadChecker ReloadChecker
+	// LeaderFailureThreshold is how many reconciliations of a leader state can fail in a row
+	// before OnLeaderFailures is called, 0 disables it.
+	LeaderFailureThreshold int
+	// LeaderRetryDelay is how long the queue waits before retrying a failed reconciliation of a leader state,
+	// doubled after every failure in a row, 0 disables the retries. Retries only happen when LeaderFailureThreshold is set.
+	LeaderRetryDelay time.Duration
+	// OnLeaderFailures is called with the last error once the configuration of a leader state failed to be applied
+	// LeaderFailureThreshold times in a row. It must not block.
+	OnLeaderFailures func(state config.State, err error)
+}
+
+// Queue serializes the reconciliations of the configuration requested by the election and the watcher.
+// Requests are coalesced: the configuration is always rendered for the last requested state,
+// and a request cancels the notification of a reconciliation that is still in flight.
+// It also periodically repairs the configuration when it drifted from the current state.
+type Queue struct {
+	config     Config
+	reconciler *config.Reconciler
+	notifier   notifier.Notifier
+	recorder   events.Recorder
+	// onApplied is called once the configuration of a state is written and Prometheus notified.
+	onApplied func(state config.State)
+
+	trigger chan struct{}
+
+	// Only accessed by the Run goroutine.
+	unwritten      bool
+	unnotified     bool
+	repairing      string
+	leaderFailures int
+
+	driftRepaired          *prometheus.CounterVec
+	leaderFailuresExceeded prometheus.Counter
+
+	mu       sync.Mutex
+	state    config.State
+	pending  bool
+	cancelFn context.CancelFunc
+}
+
+func NewQueue(cfg Config, reconciler *config.Reconciler, notifier notifier.Notifier, recorder events.Recorder, initial config.State, onApplied func(state config.State), reg prometheus.Registerer) *Queue {
+	return &Queue{
+		config:     cfg,
+		reconciler: reconciler,
+		notifier:   notifier,
+		recorder:   recorder,
+		onApplied:  onApplied,
+		trigger:    make(chan struct{}, 1),
+		state:      initial,
+		driftRepaired: promauto.With(reg).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "prometheus_elector",
+				Name:      "config_drift_repaired_total",
+				Help:      "The total amount of times a drift of the configuration has been repaired, by reason",
+			},
+			[]string{"reason"},
+		),
+		leaderFailuresExceeded: promauto.With(reg).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "prometheus_elector",
+				Name:      "config_leader_failures_exceeded_total",
+				Help:      "The total amount of times the leader configuration failed to be applied more times in a row than the threshold",
+			},
+		),
+	}
+}
+
+// Submit requests the configuration of the given state to be applied.
+func (q *Queue) Submit(state config.State) {
+	q.mu.Lock()
+	q.state = state
+	q.mu.Unlock()
+
+	q.Resync()
+}
+
+// Resync requests the configuration of the last submitted state to be applied again, for instance when the source changed.
+func (q *Queue) Resync() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending = true
+
+	// The reconciliation in flight is superseded.
+	if q.cancelFn != nil {
+		q.cancelFn()
+	}
+
+	select {
+	case q.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// State returns the last submitted state.
+func (q *Queue) State() config.State {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.state
+}
+
+// Run applies the requested configurations until the given context is done.
+// A request pending when the context is done is still written, but Prometheus is not notified.
+func (q *Queue) Run(ctx context.Context) error {
+	var resync <-chan time.Time
+
+	if q.config.ResyncPeriod > 0 {
+		ticker := time.NewTicker(q.config.ResyncPeriod)
+		defer ticker.Stop()
+
+		resync = ticker.C
+	}
+
+	// Failed leader reconciliations are retried without waiting for the drift repair, so that the failures can reach the threshold.
+	retry := time.NewTimer(0)
+	retry.Stop()
+
+	defer retry.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+		case <-q.trigger:
+		case <-resync:
+			q.checkDrift(ctx)
+			continue
+		case <-retry.C:
+			klog.InfoS("Retrying to apply the leader configuration", "failures", q.leaderFailures)
+			q.Resync()
+			continue
+		}
+
+		if ctx.Err() != nil {
+			if state, _, ok := q.next(ctx); ok {
+				_ = q.write(state)
+			}
+
+			return nil
+		}
+
+		state, reconcileCtx, ok := q.next(ctx)
+		if !ok {
+			continue
+		}
+
+		q.reconcile(reconcileCtx, state)
+		q.done()
+
+		retry.Stop()
+
+		if q.leaderFailures > 0 && q.config.LeaderRetryDelay > 0 {
+			retry.Reset(q.leaderRetryDelay())
+		}
+	}
+}
+
+// leaderRetryDelay returns how long to wait before retrying the failed reconciliation of a leader state.
+func (q *Queue) leaderRetryDelay() time.Duration {
+	delay := q.config.LeaderRetryDelay
+
+	for i := 1; i < q.leaderFailures && delay < maxLeaderRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxLeaderRetryDelay)
+}
+
+// next takes the pending request, if any, and returns a context canceled when it is superseded.
+func (q *Queue) next(ctx context.Context) (config.State, context.Context, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.pending {
+		return config.State{}, nil, false
+	}
+
+	q.pending = false
+
+	reconcileCtx, cancel := context.WithCancel(ctx)
+	q.cancelFn = cancel
+
+	return q.state, reconcileCtx, true
+}
+
+func (q *Queue) done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.cancelFn()
+	q.cancelFn = nil
+}
+
+func (q *Queue) reconcile(ctx context.Context, state config.State) {
+	if err := q.write(state); err != nil {
+		q.unwritten = true
+		q.repairing = ""
+		q.failed(state, err)
+
+		return
+	}
+
+	q.unwritten = false
+
+	err := q.notifier.Notify(ctx)
+
+	if ctx.Err() != nil {
+		klog.InfoS("Reconciliation superseded before Prometheus was notified", "role", state.Role())
+		return
+	}
+
+	if err != nil {
+		klog.ErrorS(err, "Failed to notify prometheus")
+		q.unnotified = true
+		q.repairing = ""
+		q.failed(state, err)
+
+		return
+	}
+
+	q.unnotified = false
+	q.leaderFailures = 0
+
+	if q.repairing != "" {
+		q.driftRepaired.WithLabelValues(q.repairing).Inc()
+		q.repairing = ""
+	}
+
+	if q.onApplied != nil {
+		q.onApplied(state)
+	}
+}
+
+// failed counts the reconciliations of leader states failing in a row, calling OnLeaderFailures past the threshold.
+func (q *Queue) failed(state config.State, err error) {
+	if !state.Leader || q.config.LeaderFailureThreshold == 0 {
+		q.leaderFailures = 0
+		return
+	}
+
+	q.leaderFailures++
+
+	if q.leaderFailures < q.config.LeaderFailureThreshold {
+		return
+	}
+
+	klog.InfoS("Failed to apply the leader configuration too many times in a row", "failures", q.leaderFailures, "term", state.Term)
+
+	q.leaderFailures = 0
+	q.leaderFailuresExceeded.Inc()
+
+	if q.config.OnLeaderFailures != nil {
+		q.config.OnLeaderFailures(state, err)
+	}
+}
+
+// checkDrift requests the configuration to be applied again if it drifted from the current state.
+func (q *Queue) checkDrift(ctx context.Context) {
+	q.mu.Lock()
+	state, pending := q.state, q.pending
+	q.mu.Unlock()
+
+	// The pending request applies the current state anyway.
+	if pending {
+		return
+	}
+
+	reason, err := q.drift(ctx, state)
+	if err != nil {
+		klog.ErrorS(err, "Unable to check the configuration drift")
+		return
+	}
+
+	if reason == "" {
+		return
+	}
+
+	klog.InfoS("Configuration drifted, repairing", "reason", reason, "role", state.Role())
+
+	q.repairing = reason
+	q.Resync()
+}
+
+// drift returns why the configuration drifted from the given state, empty if it didn't.
+func (q *Queue) drift(ctx context.Context, state config.State) (string, error) {
+	// The configuration may not even render, the next attempt tells.
+	if q.unwritten {
+		return driftReasonFile, nil
+	}
+
+	drifted, err := q.reconciler.Drifted(state)
+	if err != nil {
+		return "", err
+	}
+
+	if drifted {
+		return driftReasonFile, nil
+	}
+
+	if q.unnotified {
+		return driftReasonNotify, nil
+	}
+
+	if q.config.ReloadChecker == nil {
+		return "", nil
+	}
+
+	loaded, err := q.config.ReloadChecker.Loaded(ctx, q.reconciler.WrittenAt())
+	if err != nil {
+		return "", err
+	}
+
+	if !loaded {
+		return driftReasonReload, nil
+	}
+
+	return "", nil
+}
+
+// write renders the configuration of the given state to the output file.
+func (q *Queue) write(state config.State) error {
+	// Rendering is not interrupted when superseded, only the notification is.
+	if err := q.reconciler.Reconcile(context.Background(), state); err != nil {
+		klog.ErrorS(err, "Failed to reconcile configurations")
+		return err
+	}
+
+	q.recorder.Eventf(corev1.EventTypeNormal, events.ReasonConfigReconciled, "Applied the %s configuration, hash %s", state.Role(), q.reconciler.ConfigHash())
+
+	return nil
+}
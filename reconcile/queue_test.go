@@ -0,0 +1,496 @@
+package reconcile_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlevesy/prometheus-elector/config"
+	"github.com/jlevesy/prometheus-elector/events"
+	"github.com/jlevesy/prometheus-elector/reconcile"
+)
+
+const sourceConfig = `
+leader:
+  remote_write:
+  - url: http://12.3.4.5
+
+follower:
+  scrape_configs:
+  - job_name: 'foobar'
+    static_configs:
+    - targets: ['localhost:8080']
+`
+
+const followerConfig = `scrape_configs:
+- job_name: foobar
+  static_configs:
+  - targets:
+    - localhost:8080
+`
+
+var (
+	follower = config.State{Leader: false, Slots: 1}
+	leader   = config.State{Leader: true, Slots: 1, Term: 1}
+	leader2  = config.State{Leader: true, Slots: 1, Term: 2}
+)
+
+func TestQueue_SupersededNotificationIsCanceled(t *testing.T) {
+	var (
+		outputPath = setupConfig(t)
+		notifier   = newBlockingNotifier()
+		applied    appliedStates
+		queue      = reconcile.NewQueue(
+			reconcile.Config{},
+			config.NewReconciller(filepath.Join(filepath.Dir(outputPath), "config.yaml"), outputPath, false),
+			notifier,
+			events.NoopRecorder{},
+			follower,
+			applied.add,
+			prometheus.NewRegistry(),
+		)
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan struct{})
+	)
+
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	go func() {
+		defer close(done)
+
+		err := queue.Run(ctx)
+		assert.NoError(t, err)
+	}()
+
+	queue.Submit(leader)
+
+	// The leader notification is in flight and blocks until canceled.
+	<-notifier.started
+
+	queue.Submit(leader2)
+	queue.Submit(follower)
+
+	// The follower notification goes through.
+	<-notifier.started
+	notifier.release <- struct{}{}
+
+	require.Eventually(t, func() bool { return len(applied.get()) == 1 }, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []config.State{follower}, applied.get())
+	assert.Equal(t, 2, notifier.calls())
+	assert.Equal(t, follower, queue.State())
+
+	gotConfig, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, followerConfig, string(gotConfig))
+}
+
+func TestQueue_ConcurrentSubmissions(t *testing.T) {
+	var (
+		outputPath = setupConfig(t)
+		applied    appliedStates
+		queue      = reconcile.NewQueue(
+			reconcile.Config{},
+			config.NewReconciller(filepath.Join(filepath.Dir(outputPath), "config.yaml"), outputPath, false),
+			notifierFunc(func(ctx context.Context) error { return nil }),
+			events.NoopRecorder{},
+			follower,
+			applied.add,
+			prometheus.NewRegistry(),
+		)
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan struct{})
+		wg          sync.WaitGroup
+	)
+
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	go func() {
+		defer close(done)
+
+		err := queue.Run(ctx)
+		assert.NoError(t, err)
+	}()
+
+	for i := range 50 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if i%2 == 0 {
+				queue.Submit(leader)
+				return
+			}
+
+			queue.Resync()
+		}()
+	}
+
+	wg.Wait()
+
+	queue.Submit(follower)
+
+	require.Eventually(
+		t,
+		func() bool {
+			states := applied.get()
+			return len(states) > 0 && states[len(states)-1] == follower
+		},
+		time.Second,
+		10*time.Millisecond,
+	)
+
+	gotConfig, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, followerConfig, string(gotConfig))
+}
+
+func TestQueue_WritesPendingStateOnShutdown(t *testing.T) {
+	var (
+		outputPath = setupConfig(t)
+		queue      = reconcile.NewQueue(
+			reconcile.Config{},
+			config.NewReconciller(filepath.Join(filepath.Dir(outputPath), "config.yaml"), outputPath, false),
+			notifierFunc(func(ctx context.Context) error {
+				t.Error("unexpected notification")
+				return nil
+			}),
+			events.NoopRecorder{},
+			leader,
+			nil,
+			prometheus.NewRegistry(),
+		)
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+
+	cancel()
+	queue.Submit(follower)
+
+	err := queue.Run(ctx)
+	require.NoError(t, err)
+
+	gotConfig, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, followerConfig, string(gotConfig))
+}
+
+func TestQueue_RepairsDrift(t *testing.T) {
+	for _, testCase := range []struct {
+		desc            string
+		notifyErrors    int
+		notLoaded       int
+		editOutput      bool
+		wantReason      string
+		wantNotifyCalls int
+	}{
+		{
+			desc:            "edited output file",
+			editOutput:      true,
+			wantReason:      "file",
+			wantNotifyCalls: 2,
+		},
+		{
+			desc:            "failed notification",
+			notifyErrors:    1,
+			wantReason:      "notify",
+			wantNotifyCalls: 2,
+		},
+		{
+			desc:            "configuration not loaded by Prometheus",
+			notLoaded:       1,
+			wantReason:      "reload",
+			wantNotifyCalls: 2,
+		},
+	} {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var (
+				outputPath = setupConfig(t)
+				reg        = prometheus.NewRegistry()
+
+				mu           sync.Mutex
+				notifyCalls  int
+				notifyErrors = testCase.notifyErrors
+				notLoaded    = testCase.notLoaded
+
+				queue = reconcile.NewQueue(
+					reconcile.Config{
+						ResyncPeriod: 20 * time.Millisecond,
+						ReloadChecker: reloadCheckerFunc(func(context.Context, time.Time) (bool, error) {
+							mu.Lock()
+							defer mu.Unlock()
+
+							if notLoaded > 0 {
+								notLoaded--
+								return false, nil
+							}
+
+							return true, nil
+						}),
+					},
+					config.NewReconciller(filepath.Join(filepath.Dir(outputPath), "config.yaml"), outputPath, false),
+					notifierFunc(func(context.Context) error {
+						mu.Lock()
+						defer mu.Unlock()
+
+						notifyCalls++
+
+						if notifyErrors > 0 {
+							notifyErrors--
+							return errors.New("nope")
+						}
+
+						return nil
+					}),
+					events.NoopRecorder{},
+					follower,
+					nil,
+					reg,
+				)
+				ctx, cancel = context.WithCancel(context.Background())
+				done        = make(chan struct{})
+			)
+
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			go func() {
+				defer close(done)
+
+				err := queue.Run(ctx)
+				assert.NoError(t, err)
+			}()
+
+			queue.Submit(follower)
+
+			if testCase.editOutput {
+				require.Eventually(t, func() bool { return fileContent(t, outputPath) == followerConfig }, time.Second, 5*time.Millisecond)
+
+				err := os.WriteFile(outputPath, []byte("global: {}\n"), 0600)
+				require.NoError(t, err)
+			}
+
+			wantMetrics := fmt.Sprintf(`
+# HELP prometheus_elector_config_drift_repaired_total The total amount of times a drift of the configuration has been repaired, by reason
+# TYPE prometheus_elector_config_drift_repaired_total counter
+prometheus_elector_config_drift_repaired_total{reason=%q} 1
+`, testCase.wantReason)
+
+			require.Eventually(
+				t,
+				func() bool {
+					return testutil.GatherAndCompare(reg, strings.NewReader(wantMetrics), "prometheus_elector_config_drift_repaired_total") == nil
+				},
+				time.Second,
+				5*time.Millisecond,
+			)
+
+			// No further repair once the configuration is back in sync.
+			time.Sleep(100 * time.Millisecond)
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(wantMetrics), "prometheus_elector_config_drift_repaired_total"))
+			assert.Equal(t, followerConfig, fileContent(t, outputPath))
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			assert.Equal(t, testCase.wantNotifyCalls, notifyCalls)
+		})
+	}
+}
+
+func TestQueue_LeaderFailures(t *testing.T) {
+	for _, testCase := range []struct {
+		desc         string
+		resyncPeriod time.Duration
+	}{
+		{
+			desc:         "retried by the queue and the drift repair",
+			resyncPeriod: 20 * time.Millisecond,
+		},
+		{
+			desc:         "retried by the queue only",
+			resyncPeriod: 0,
+		},
+	} {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var (
+				outputPath = setupConfig(t)
+				reg        = prometheus.NewRegistry()
+				failures   = make(chan config.State, 1)
+				queue      = reconcile.NewQueue(
+					reconcile.Config{
+						ResyncPeriod:           testCase.resyncPeriod,
+						LeaderFailureThreshold: 3,
+						LeaderRetryDelay:       10 * time.Millisecond,
+						OnLeaderFailures: func(state config.State, err error) {
+							assert.EqualError(t, err, "nope")
+
+							select {
+							case failures <- state:
+							default:
+							}
+						},
+					},
+					config.NewReconciller(filepath.Join(filepath.Dir(outputPath), "config.yaml"), outputPath, false),
+					notifierFunc(func(context.Context) error { return errors.New("nope") }),
+					events.NoopRecorder{},
+					follower,
+					nil,
+					reg,
+				)
+				ctx, cancel = context.WithCancel(context.Background())
+				done        = make(chan struct{})
+			)
+
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			go func() {
+				defer close(done)
+
+				err := queue.Run(ctx)
+				assert.NoError(t, err)
+			}()
+
+			// Failures to apply the follower configuration don't count.
+			queue.Submit(follower)
+			time.Sleep(100 * time.Millisecond)
+
+			select {
+			case <-failures:
+				t.Fatal("follower failures shouldn't be reported")
+			default:
+			}
+
+			// The leader configuration is retried until failing too many times.
+			queue.Submit(leader)
+
+			select {
+			case state := <-failures:
+				assert.Equal(t, leader, state)
+			case <-time.After(time.Second):
+				t.Fatal("leader failures weren't reported")
+			}
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP prometheus_elector_config_leader_failures_exceeded_total The total amount of times the leader configuration failed to be applied more times in a row than the threshold
+# TYPE prometheus_elector_config_leader_failures_exceeded_total counter
+prometheus_elector_config_leader_failures_exceeded_total 1
+`), "prometheus_elector_config_leader_failures_exceeded_total"))
+		})
+	}
+}
+
+func fileContent(t *testing.T, path string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	return string(content)
+}
+
+func setupConfig(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(sourceConfig), 0600)
+	require.NoError(t, err)
+
+	return filepath.Join(dir, "prometheus.yaml")
+}
+
+type appliedStates struct {
+	mu     sync.Mutex
+	states []config.State
+}
+
+func (a *appliedStates) add(state config.State) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.states = append(a.states, state)
+}
+
+func (a *appliedStates) get() []config.State {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]config.State(nil), a.states...)
+}
+
+// blockingNotifier blocks every notification until it is released or its context is canceled.
+type blockingNotifier struct {
+	started chan struct{}
+	release chan struct{}
+
+	mu    sync.Mutex
+	count int
+}
+
+func newBlockingNotifier() *blockingNotifier {
+	return &blockingNotifier{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (n *blockingNotifier) Notify(ctx context.Context) error {
+	n.mu.Lock()
+	n.count++
+	n.mu.Unlock()
+
+	n.started <- struct{}{}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-n.release:
+		return nil
+	}
+}
+
+func (n *blockingNotifier) calls() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.count
+}
+
+type reloadCheckerFunc func(ctx context.Context, writtenAt time.Time) (bool, error)
+
+func (r reloadCheckerFunc) Loaded(ctx context.Context, writtenAt time.Time) (bool, error) {
+	return r(ctx, writtenAt)
+}
+
+type notifierFunc func(ctx context.Context) error
+
+func (n notifierFunc) Notify(ctx context.Context) error {
+	return n(ctx)
+}
@@ -0,0 +1,85 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	reloadSuccessfulMetric = "prometheus_config_last_reload_successful"
+	reloadTimestampMetric  = "prometheus_config_last_reload_success_timestamp_seconds"
+)
+
+// ReloadChecker tells if Prometheus loaded the configuration written at the given time.
+type ReloadChecker interface {
+	Loaded(ctx context.Context, writtenAt time.Time) (bool, error)
+}
+
+type httpReloadChecker struct {
+	url        string
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+// NewHTTPReloadChecker checks the reload metrics exposed by Prometheus on the given URL.
+func NewHTTPReloadChecker(url string, timeout time.Duration) ReloadChecker {
+	return &httpReloadChecker{
+		url:        url,
+		timeout:    timeout,
+		httpClient: http.DefaultClient,
+	}
+}
+
+// Loaded tells if the last reload of Prometheus succeeded and happened after the configuration has been written.
+func (c *httpReloadChecker) Loaded(ctx context.Context, writtenAt time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, http.NoBody)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var parser expfmt.TextParser
+
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse the Prometheus metrics: %w", err)
+	}
+
+	successful, ok := families[reloadSuccessfulMetric]
+	if !ok || len(successful.GetMetric()) == 0 {
+		return false, errors.New("missing metric " + reloadSuccessfulMetric)
+	}
+
+	if successful.GetMetric()[0].GetGauge().GetValue() != 1 {
+		return false, nil
+	}
+
+	timestamp, ok := families[reloadTimestampMetric]
+	if !ok || len(timestamp.GetMetric()) == 0 {
+		return false, errors.New("missing metric " + reloadTimestampMetric)
+	}
+
+	// The reload timestamp only has a second precision.
+	reloadedAt := math.Ceil(timestamp.GetMetric()[0].GetGauge().GetValue())
+
+	return reloadedAt >= float64(writtenAt.Unix()), nil
+}
@@ -0,0 +1,84 @@
+package reconcile_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlevesy/prometheus-elector/reconcile"
+)
+
+func TestHTTPReloadChecker(t *testing.T) {
+	writtenAt := time.Unix(1700000000, 0)
+
+	for _, testCase := range []struct {
+		desc       string
+		metrics    string
+		status     int
+		wantLoaded bool
+		wantErr    bool
+	}{
+		{
+			desc:       "reloaded after the write",
+			metrics:    reloadMetrics(1, writtenAt.Add(time.Second)),
+			status:     http.StatusOK,
+			wantLoaded: true,
+		},
+		{
+			desc:       "reloaded before the write",
+			metrics:    reloadMetrics(1, writtenAt.Add(-time.Minute)),
+			status:     http.StatusOK,
+			wantLoaded: false,
+		},
+		{
+			desc:       "last reload failed",
+			metrics:    reloadMetrics(0, writtenAt.Add(time.Second)),
+			status:     http.StatusOK,
+			wantLoaded: false,
+		},
+		{
+			desc:    "missing metrics",
+			metrics: "# TYPE up gauge\nup 1\n",
+			status:  http.StatusOK,
+			wantErr: true,
+		},
+		{
+			desc:    "unexpected status",
+			status:  http.StatusInternalServerError,
+			wantErr: true,
+		},
+	} {
+		t.Run(testCase.desc, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodGet, r.Method)
+
+				rw.WriteHeader(testCase.status)
+				_, _ = rw.Write([]byte(testCase.metrics))
+			}))
+			defer srv.Close()
+
+			loaded, err := reconcile.NewHTTPReloadChecker(srv.URL, time.Second).Loaded(context.Background(), writtenAt)
+			if testCase.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.wantLoaded, loaded)
+		})
+	}
+}
+
+func reloadMetrics(successful int, reloadedAt time.Time) string {
+	return fmt.Sprintf(`# TYPE prometheus_config_last_reload_successful gauge
+prometheus_config_last_reload_successful %d
+# TYPE prometheus_config_last_reload_success_timestamp_seconds gauge
+prometheus_config_last_reload_success_timestamp_seconds %de+00
+`, successful, reloadedAt.Unix())
+}
@@ -0,0 +1,269 @@
+package splitbrain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/jlevesy/prometheus-elector/api"
+	"github.com/jlevesy/prometheus-elector/election"
+	"github.com/jlevesy/prometheus-elector/events"
+)
+
+// PeerURLFunc returns the base URL of the API of the given member.
+type PeerURLFunc func(memberID string) string
+
+// HeadlessServicePeers reaches the API of the members through the given headless service, as the leader proxy does.
+func HeadlessServicePeers(serviceName string, port uint) PeerURLFunc {
+	return func(memberID string) string {
+		return fmt.Sprintf("http://%s.%s:%d", memberID, serviceName, port)
+	}
+}
+
+type Config struct {
+	PeerURL PeerURLFunc
+	// How often the peers are polled.
+	Period time.Duration
+	// HTTP timeout of a poll.
+	Timeout time.Duration
+	// How long more than one member has to claim the leadership before a split-brain is reported.
+	Threshold time.Duration
+	// StepDown makes a leader step down when a split-brain is reported, unless it holds the highest term.
+	StepDown bool
+}
+
+// claimant is a member claiming the leadership.
+type claimant struct {
+	memberID string
+	term     int64
+}
+
+// Detector polls the leader status of the peers and reports when more than one member claims the leadership.
+type Detector struct {
+	config   Config
+	memberID string
+	status   election.Status
+	members  election.MemberLister
+	stepDown func(ctx context.Context) error
+	recorder events.Recorder
+
+	httpClient *http.Client
+
+	// Only accessed by the Run loop.
+	claimedSince time.Time
+	detected     bool
+
+	claimants prometheus.Gauge
+	detection prometheus.Gauge
+	splits    prometheus.Counter
+	stepDowns prometheus.Counter
+}
+
+func NewDetector(
+	cfg Config,
+	memberID string,
+	status election.Status,
+	members election.MemberLister,
+	stepDown func(ctx context.Context) error,
+	recorder events.Recorder,
+	reg prometheus.Registerer,
+) *Detector {
+	return &Detector{
+		config:     cfg,
+		memberID:   memberID,
+		status:     status,
+		members:    members,
+		stepDown:   stepDown,
+		recorder:   recorder,
+		httpClient: http.DefaultClient,
+		claimants: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "prometheus_elector",
+				Name:      "split_brain_claimants",
+				Help:      "The amount of members claiming the leadership during the last check",
+			},
+		),
+		detection: promauto.With(reg).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: "prometheus_elector",
+				Name:      "split_brain_detected",
+				Help:      "Set to 1 while more than one member claims the leadership for longer than the threshold",
+			},
+		),
+		splits: promauto.With(reg).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "prometheus_elector",
+				Name:      "split_brain_detected_total",
+				Help:      "The total amount of split-brains detected",
+			},
+		),
+		stepDowns: promauto.With(reg).NewCounter(
+			prometheus.CounterOpts{
+				Namespace: "prometheus_elector",
+				Name:      "split_brain_step_downs_total",
+				Help:      "The total amount of times the member stepped down because of a split-brain",
+			},
+		),
+	}
+}
+
+// Run checks the peers every period until the given context is done.
+func (d *Detector) Run(ctx context.Context) error {
+	klog.InfoS("Starting split-brain detection", "period", d.config.Period, "threshold", d.config.Threshold)
+
+	ticker := time.NewTicker(d.config.Period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			d.check(ctx)
+		}
+	}
+}
+
+func (d *Detector) check(ctx context.Context) {
+	claimants, err := d.collectClaimants(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			klog.ErrorS(err, "Unable to check for a split-brain")
+		}
+
+		return
+	}
+
+	d.claimants.Set(float64(len(claimants)))
+
+	if len(claimants) < 2 {
+		if d.detected {
+			klog.InfoS("Split-brain resolved")
+			d.detection.Set(0)
+		}
+
+		d.claimedSince = time.Time{}
+		d.detected = false
+
+		return
+	}
+
+	now := time.Now()
+
+	if d.claimedSince.IsZero() {
+		d.claimedSince = now
+	}
+
+	if d.detected || now.Sub(d.claimedSince) < d.config.Threshold {
+		return
+	}
+
+	d.detected = true
+	d.detection.Set(1)
+	d.splits.Inc()
+
+	ids := make([]string, len(claimants))
+	for i, c := range claimants {
+		ids[i] = fmt.Sprintf("%s (term %d)", c.memberID, c.term)
+	}
+
+	klog.InfoS("Split-brain detected, more than one member claims the leadership", "claimants", ids, "since", d.claimedSince)
+	d.recorder.ElectionEventf(corev1.EventTypeWarning, events.ReasonSplitBrain, "Split-brain detected, members %s claim the leadership", strings.Join(ids, ", "))
+
+	if !d.config.StepDown || !d.status.IsLeader() || legitimate(claimants) == d.memberID {
+		return
+	}
+
+	klog.InfoS("Stepping down because of the split-brain", "legitimateLeader", legitimate(claimants))
+	d.stepDowns.Inc()
+
+	if err := d.stepDown(ctx); err != nil {
+		klog.ErrorS(err, "Unable to step down because of the split-brain")
+	}
+}
+
+// collectClaimants returns the members claiming the leadership, including the local member.
+// Peers that can't be reached are ignored.
+func (d *Detector) collectClaimants(ctx context.Context) ([]claimant, error) {
+	members, err := d.members.Members(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var claimants []claimant
+
+	if d.status.IsLeader() {
+		claimants = append(claimants, claimant{memberID: d.memberID, term: d.status.GetTerm()})
+	}
+
+	for _, member := range members {
+		if member.ID == d.memberID || member.Stale || member.State == election.MemberStateLeft {
+			continue
+		}
+
+		status, err := d.leaderStatus(ctx, member.ID)
+		if err != nil {
+			klog.ErrorS(err, "Unable to get the leader status of a peer", "member", member.ID)
+			continue
+		}
+
+		if status.IsLeader {
+			claimants = append(claimants, claimant{memberID: member.ID, term: status.Term})
+		}
+	}
+
+	return claimants, nil
+}
+
+func (d *Detector) leaderStatus(ctx context.Context, memberID string) (*api.LeaderStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.config.PeerURL(memberID)+"/_elector/leader", http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var status api.LeaderStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// legitimate returns the claimant that keeps the leadership: the one holding the highest term, which acquired the lease last.
+// Ties are broken by member ID.
+func legitimate(claimants []claimant) string {
+	sorted := append([]claimant(nil), claimants...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].term != sorted[j].term {
+			return sorted[i].term > sorted[j].term
+		}
+
+		return sorted[i].memberID < sorted[j].memberID
+	})
+
+	return sorted[0].memberID
+}
@@ -0,0 +1,167 @@
+package splitbrain_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jlevesy/prometheus-elector/api"
+	"github.com/jlevesy/prometheus-elector/election"
+	"github.com/jlevesy/prometheus-elector/events"
+	"github.com/jlevesy/prometheus-elector/splitbrain"
+)
+
+func TestDetector(t *testing.T) {
+	for _, testCase := range []struct {
+		desc         string
+		localTerm    int64
+		wantStepDown bool
+	}{
+		{
+			desc:         "steps down when a peer holds a higher term",
+			localTerm:    2,
+			wantStepDown: true,
+		},
+		{
+			desc:         "keeps the leadership when holding the highest term",
+			localTerm:    4,
+			wantStepDown: false,
+		},
+	} {
+		t.Run(testCase.desc, func(t *testing.T) {
+			var (
+				ctx, cancel = context.WithCancel(context.Background())
+				reg         = prometheus.NewRegistry()
+				steppedDown atomic.Bool
+				barLeading  atomic.Bool
+				peers       = map[string]*httptest.Server{
+					"bar": newPeer(t, &barLeading, 3),
+					"biz": newPeer(t, &atomic.Bool{}, 3),
+				}
+				detector = splitbrain.NewDetector(
+					splitbrain.Config{
+						PeerURL:   func(memberID string) string { return peers[memberID].URL },
+						Period:    20 * time.Millisecond,
+						Timeout:   time.Second,
+						Threshold: 100 * time.Millisecond,
+						StepDown:  true,
+					},
+					"foo",
+					&statusStub{isLeader: true, term: testCase.localTerm},
+					&memberListerStub{
+						members: []election.Member{
+							{ID: "foo", State: election.MemberStateLeader},
+							{ID: "bar", State: election.MemberStateLeader},
+							{ID: "biz", State: election.MemberStateFollower},
+							{ID: "buz", State: election.MemberStateFollower, Stale: true},
+						},
+					},
+					func(context.Context) error {
+						steppedDown.Store(true)
+						return nil
+					},
+					events.NoopRecorder{},
+					reg,
+				)
+				detectorDone = make(chan struct{})
+			)
+
+			defer cancel()
+
+			barLeading.Store(true)
+
+			go func() {
+				defer close(detectorDone)
+
+				assert.NoError(t, detector.Run(ctx))
+			}()
+
+			require.Eventually(t, func() bool {
+				return gaugeValue(t, reg, "prometheus_elector_split_brain_detected") == 1
+			}, 5*time.Second, 10*time.Millisecond)
+
+			assert.Equal(t, testCase.wantStepDown, steppedDown.Load())
+
+			// The split-brain is resolved once the peer stops claiming the leadership.
+			barLeading.Store(false)
+
+			require.Eventually(t, func() bool {
+				return gaugeValue(t, reg, "prometheus_elector_split_brain_detected") == 0
+			}, 5*time.Second, 10*time.Millisecond)
+
+			cancel()
+			<-detectorDone
+
+			err := testutil.GatherAndCompare(
+				reg,
+				strings.NewReader(`
+# HELP prometheus_elector_split_brain_detected_total The total amount of split-brains detected
+# TYPE prometheus_elector_split_brain_detected_total counter
+prometheus_elector_split_brain_detected_total 1
+`),
+				"prometheus_elector_split_brain_detected_total",
+			)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func newPeer(t *testing.T, leading *atomic.Bool, term int64) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_elector/leader" {
+			http.NotFound(rw, r)
+			return
+		}
+
+		_ = json.NewEncoder(rw).Encode(api.LeaderStatus{IsLeader: leading.Load(), Term: term})
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func gaugeValue(t *testing.T, reg *prometheus.Registry, name string) float64 {
+	t.Helper()
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+
+	return 0
+}
+
+type statusStub struct {
+	isLeader bool
+	term     int64
+}
+
+func (s *statusStub) IsLeader() bool        { return s.isLeader }
+func (s *statusStub) GetLeader() string     { return "foo" }
+func (s *statusStub) IsCordoned() bool      { return false }
+func (s *statusStub) GetZone() string       { return "" }
+func (s *statusStub) GetLeaderZone() string { return "" }
+func (s *statusStub) GetTerm() int64        { return s.term }
+
+type memberListerStub struct {
+	members []election.Member
+}
+
+func (s *memberListerStub) Members(context.Context) ([]election.Member, error) { return s.members, nil }
@@ -7,20 +7,19 @@ import (
 
 	"github.com/fsnotify/fsnotify"
 	"k8s.io/klog/v2"
-
-	"github.com/jlevesy/prometheus-elector/config"
-	"github.com/jlevesy/prometheus-elector/election"
-	"github.com/jlevesy/prometheus-elector/notifier"
 )
 
+// Resyncer applies the configuration again for the current state.
+type Resyncer interface {
+	Resync()
+}
+
 type FileWatcher struct {
-	fsWatcher     *fsnotify.Watcher
-	reconciler    *config.Reconciler
-	leaderChecker election.LeaderChecker
-	notifier      notifier.Notifier
+	fsWatcher *fsnotify.Watcher
+	resyncer  Resyncer
 }
 
-func New(path string, reconciler *config.Reconciler, notifier notifier.Notifier, leaderChecker election.LeaderChecker) (*FileWatcher, error) {
+func New(path string, resyncer Resyncer) (*FileWatcher, error) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, fmt.Errorf("unable to create fsnotify watcher: %w", err)
@@ -33,10 +32,8 @@ func New(path string, reconciler *config.Reconciler, notifier notifier.Notifier,
 	klog.InfoS("Watching config directory", "path", path)
 
 	return &FileWatcher{
-		fsWatcher:     watcher,
-		leaderChecker: leaderChecker,
-		reconciler:    reconciler,
-		notifier:      notifier,
+		fsWatcher: watcher,
+		resyncer:  resyncer,
 	}, nil
 }
 
@@ -59,15 +56,7 @@ func (f *FileWatcher) Watch(ctx context.Context) error {
 
 			klog.Info("Configuration changed, reconciling...")
 
-			if err := f.reconciler.Reconcile(ctx, f.leaderChecker.IsLeader()); err != nil {
-				klog.ErrorS(err, "Reconciler reported an error")
-				continue
-			}
-
-			if err := f.notifier.Notify(ctx); err != nil {
-				klog.ErrorS(err, "Unable to notify prometheus")
-				continue
-			}
+			f.resyncer.Resync()
 		case err, ok := <-f.fsWatcher.Errors:
 			if !ok {
 				return nil
@@ -7,7 +7,10 @@ import (
 	"testing"
 
 	"github.com/jlevesy/prometheus-elector/config"
+	"github.com/jlevesy/prometheus-elector/events"
+	"github.com/jlevesy/prometheus-elector/reconcile"
 	"github.com/jlevesy/prometheus-elector/watcher"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -44,7 +47,7 @@ func TestFileWatcher(t *testing.T) {
 		configPath = filepath.Join(dir, fileName)
 		destPath   = filepath.Join(dir, destFileName)
 
-		reconciler = config.NewReconciller(configPath, destPath)
+		reconciler = config.NewReconciller(configPath, destPath, false)
 
 		notifiedCh = make(chan struct{})
 		notifier   = func() error {
@@ -60,7 +63,14 @@ func TestFileWatcher(t *testing.T) {
 	err := simulateConfigmapWrite(dir, fileName, []byte(defaultConfig))
 	require.NoError(t, err)
 
-	watcher, err := watcher.New(dir, reconciler, notifierFunc(notifier), leaderCheckerFunc(func() bool { return false }))
+	queue := reconcile.NewQueue(reconcile.Config{}, reconciler, notifierFunc(notifier), events.NoopRecorder{}, config.State{Slots: 1}, nil, prometheus.NewRegistry())
+
+	go func() {
+		err := queue.Run(ctx)
+		require.NoError(t, err)
+	}()
+
+	watcher, err := watcher.New(dir, queue)
 	require.NoError(t, err)
 
 	defer watcher.Close()
@@ -113,7 +123,3 @@ type notifierFunc func() error
 func (n notifierFunc) Notify(context.Context) error {
 	return n()
 }
-
-type leaderCheckerFunc func() bool
-
-func (l leaderCheckerFunc) IsLeader() bool { return l() }